@@ -0,0 +1,201 @@
+// Package channel implements unidirectional payment channels. The payer locks
+// the channel capacity in a funding output both parties control, then pays the
+// payee off chain by signing updates that grow the amount the payee receives
+// on close. The payee closes the channel with the latest update at any time;
+// if the payee never does, the payer reclaims the capacity after the timeout.
+package channel
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// Channel describes a funded payment channel. Both parties have to agree on all
+// of its fields, since they determine the close transaction the updates sign.
+type Channel struct {
+	Payer    *crypto.PublicKey
+	Payee    *crypto.PublicKey
+	Capacity int64
+	// Fee is left unspent by the close and refund transactions. Blocks
+	// don't collect fees, so it is burned.
+	Fee     int64
+	Timeout int32
+
+	FundingTxHash   []byte
+	FundingOutIndex uint32
+}
+
+// FundingOutput returns the output the funding transaction has to contain at
+// FundingOutIndex.
+func (c *Channel) FundingOutput() *proto.TxOutput {
+	return types.NewChannelOutput(c.Capacity, c.Payer, c.Payee, c.Timeout)
+}
+
+func (c *Channel) validate() error {
+	if c.Payer == nil || c.Payee == nil {
+		return fmt.Errorf("channel needs a payer and a payee")
+	}
+	if len(c.FundingTxHash) == 0 {
+		return fmt.Errorf("channel is not funded")
+	}
+	if c.Fee < 0 || c.Capacity <= c.Fee {
+		return fmt.Errorf("channel capacity %d does not cover the fee %d", c.Capacity, c.Fee)
+	}
+	return types.ValidateChannelLock(c.FundingOutput().Channel)
+}
+
+// closeTransaction returns the unsigned transaction paying amount to the payee
+// and the rest of the capacity, less the fee, back to the payer. Outputs that
+// would be empty are left out.
+func (c *Channel) closeTransaction(amount int64) *proto.Transaction {
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   c.FundingTxHash,
+			PrevOutIndex: c.FundingOutIndex,
+		}},
+	}
+	if amount > 0 {
+		tx.Outputs = append(tx.Outputs, &proto.TxOutput{
+			Amount:  amount,
+			Address: c.Payee.Address().Bytes(),
+		})
+	}
+	if change := c.Capacity - c.Fee - amount; change > 0 {
+		tx.Outputs = append(tx.Outputs, &proto.TxOutput{
+			Amount:  change,
+			Address: c.Payer.Address().Bytes(),
+		})
+	}
+	return tx
+}
+
+func (c *Channel) isFunding(update *proto.ChannelUpdate) bool {
+	return bytes.Equal(update.FundingTxHash, c.FundingTxHash) && update.FundingOutIndex == c.FundingOutIndex
+}
+
+// Payer is the side of the channel that pays.
+type Payer struct {
+	channel *Channel
+	privKey *crypto.PrivateKey
+	paid    int64
+}
+
+func NewPayer(channel *Channel, privKey *crypto.PrivateKey) (*Payer, error) {
+	if err := channel.validate(); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(privKey.Public().Bytes(), channel.Payer.Bytes()) {
+		return nil, fmt.Errorf("private key is not the key of the channel payer")
+	}
+	return &Payer{
+		channel: channel,
+		privKey: privKey,
+	}, nil
+}
+
+// Paid returns the total amount paid over the channel so far.
+func (p *Payer) Paid() int64 {
+	return p.paid
+}
+
+// Pay pays amount more to the payee. The returned update carries the new total
+// and is sent to the payee, who can close the channel with it.
+func (p *Payer) Pay(amount int64) (*proto.ChannelUpdate, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid channel payment %d", amount)
+	}
+	total := p.paid + amount
+	if total > p.channel.Capacity-p.channel.Fee {
+		return nil, fmt.Errorf("channel payment of %d exceeds the remaining capacity", amount)
+	}
+
+	tx := p.channel.closeTransaction(total)
+	update := &proto.ChannelUpdate{
+		FundingTxHash:   p.channel.FundingTxHash,
+		FundingOutIndex: p.channel.FundingOutIndex,
+		Amount:          total,
+		Signature:       types.SignTransaction(p.privKey, tx).Bytes(),
+	}
+	p.paid = total
+	return update, nil
+}
+
+// RefundTransaction returns the signed transaction giving the capacity, less
+// the fee, back to the payer. The chain accepts it from the channel timeout on.
+func (p *Payer) RefundTransaction() *proto.Transaction {
+	tx := p.channel.closeTransaction(0)
+	tx.Inputs[0].PublicKey = p.channel.Payer.Bytes()
+	tx.Inputs[0].Signature = types.SignTransaction(p.privKey, tx).Bytes()
+	return tx
+}
+
+// Payee is the side of the channel that gets paid.
+type Payee struct {
+	channel *Channel
+	privKey *crypto.PrivateKey
+	latest  *proto.ChannelUpdate
+}
+
+func NewPayee(channel *Channel, privKey *crypto.PrivateKey) (*Payee, error) {
+	if err := channel.validate(); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(privKey.Public().Bytes(), channel.Payee.Bytes()) {
+		return nil, fmt.Errorf("private key is not the key of the channel payee")
+	}
+	return &Payee{
+		channel: channel,
+		privKey: privKey,
+	}, nil
+}
+
+// Balance returns the amount the latest accepted update pays the payee.
+func (p *Payee) Balance() int64 {
+	if p.latest == nil {
+		return 0
+	}
+	return p.latest.Amount
+}
+
+// Receive accepts an update from the payer. Updates have to be for this
+// channel, properly signed, and pay more than the previous one.
+func (p *Payee) Receive(update *proto.ChannelUpdate) error {
+	if !p.channel.isFunding(update) {
+		return fmt.Errorf("update is for another channel")
+	}
+	if update.Amount <= p.Balance() {
+		return fmt.Errorf("update amount %d does not exceed the balance %d", update.Amount, p.Balance())
+	}
+	if update.Amount > p.channel.Capacity-p.channel.Fee {
+		return fmt.Errorf("update amount %d exceeds the channel capacity", update.Amount)
+	}
+
+	sig, err := crypto.ParseSignature(update.Signature)
+	if err != nil {
+		return err
+	}
+	tx := p.channel.closeTransaction(update.Amount)
+	if !sig.Verify(p.channel.Payer, types.SigHash(tx)) {
+		return fmt.Errorf("invalid channel update signature")
+	}
+
+	p.latest = update
+	return nil
+}
+
+// CloseTransaction returns the transaction closing the channel with the latest
+// accepted update, signed by both parties.
+func (p *Payee) CloseTransaction() (*proto.Transaction, error) {
+	if p.latest == nil {
+		return nil, fmt.Errorf("no payment received over the channel")
+	}
+	tx := p.channel.closeTransaction(p.latest.Amount)
+	payeeSig := types.SignTransaction(p.privKey, tx)
+	tx.Inputs[0].Signatures = [][]byte{p.latest.Signature, payeeSig.Bytes()}
+	return tx, nil
+}
@@ -0,0 +1,112 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/protobuf/proto"
+)
+
+func testChannel(payer, payee *crypto.PrivateKey) *Channel {
+	return &Channel{
+		Payer:         payer.Public(),
+		Payee:         payee.Public(),
+		Capacity:      1000,
+		Fee:           10,
+		Timeout:       100,
+		FundingTxHash: util.RandomHash(),
+	}
+}
+
+func TestPayeeRejectsInvalidUpdates(t *testing.T) {
+	var (
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = testChannel(payerKey, payeeKey)
+	)
+
+	payer, err := NewPayer(ch, payerKey)
+	require.Nil(t, err)
+	payee, err := NewPayee(ch, payeeKey)
+	require.Nil(t, err)
+
+	_, err = payee.CloseTransaction()
+	require.NotNil(t, err)
+
+	first, err := payer.Pay(300)
+	require.Nil(t, err)
+	second, err := payer.Pay(200)
+	require.Nil(t, err)
+	assert.Equal(t, int64(500), payer.Paid())
+
+	require.Nil(t, payee.Receive(second))
+	// an older update pays less and is not accepted
+	require.NotNil(t, payee.Receive(first))
+
+	forged := pb.Clone(second).(*proto.ChannelUpdate)
+	forged.Amount = 900
+	require.NotNil(t, payee.Receive(forged))
+
+	other := pb.Clone(second).(*proto.ChannelUpdate)
+	other.FundingTxHash = util.RandomHash()
+	require.NotNil(t, payee.Receive(other))
+	assert.Equal(t, int64(500), payee.Balance())
+
+	// the payer can't pay more than the capacity less the fee
+	_, err = payer.Pay(491)
+	require.NotNil(t, err)
+	_, err = payer.Pay(490)
+	require.Nil(t, err)
+}
+
+func TestCloseTransaction(t *testing.T) {
+	var (
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = testChannel(payerKey, payeeKey)
+	)
+
+	payer, err := NewPayer(ch, payerKey)
+	require.Nil(t, err)
+	payee, err := NewPayee(ch, payeeKey)
+	require.Nil(t, err)
+
+	update, err := payer.Pay(990)
+	require.Nil(t, err)
+	require.Nil(t, payee.Receive(update))
+
+	tx, err := payee.CloseTransaction()
+	require.Nil(t, err)
+	// nothing is left for the payer
+	require.Equal(t, 1, len(tx.Outputs))
+	assert.Equal(t, int64(990), tx.Outputs[0].Amount)
+
+	lock := ch.FundingOutput().Channel
+	require.Nil(t, types.VerifyChannelSpend(lock, tx.Inputs[0], types.SigHash(tx), 1))
+
+	refund := payer.RefundTransaction()
+	require.NotNil(t, types.VerifyChannelSpend(lock, refund.Inputs[0], types.SigHash(refund), 99))
+	require.Nil(t, types.VerifyChannelSpend(lock, refund.Inputs[0], types.SigHash(refund), 100))
+}
+
+func TestNewPayerWithWrongKey(t *testing.T) {
+	var (
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = testChannel(payerKey, payeeKey)
+	)
+
+	_, err := NewPayer(ch, payeeKey)
+	require.NotNil(t, err)
+	_, err = NewPayee(ch, payerKey)
+	require.NotNil(t, err)
+
+	ch.FundingTxHash = nil
+	_, err = NewPayer(ch, payerKey)
+	require.NotNil(t, err)
+}
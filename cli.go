@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/node"
+)
+
+// passwordEnv holds the password of the keystore used by commands that sign.
+const passwordEnv = "CHLOCKBANE_KEYSTORE_PASSWORD"
+
+// runCommand runs the command line tool named by args[0] with the remaining
+// arguments.
+func runCommand(args []string) error {
+	switch args[0] {
+	case "node":
+		return runNode(args[1:])
+	case "signmessage":
+		return signMessage(args[1:])
+	case "verifymessage":
+		return verifyMessage(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q, expected node, signmessage or verifymessage", args[0])
+	}
+}
+
+func runNode(args []string) error {
+	fs := flag.NewFlagSet("node", flag.ExitOnError)
+	listen := fs.String("listen", ":3000", "address to listen on")
+	peers := fs.String("peers", "", "comma separated addresses of nodes to connect to")
+	keystore := fs.String("keystore", "", "encrypted keystore file of the validator key, unlocked with $"+passwordEnv)
+	validators := fs.String("validators", "", "comma separated genesis validators as hex public key:bond")
+	threshold := fs.Int("governance-threshold", node.DefaultChainParams().GovernanceThreshold, "percentage of validators that must approve a validator set change")
+	fs.Parse(args)
+
+	params := node.DefaultChainParams()
+	params.GovernanceThreshold = *threshold
+	genesis, err := parseValidators(*validators)
+	if err != nil {
+		return err
+	}
+	params.GenesisValidators = genesis
+
+	cfg := node.ServerConfig{
+		Version:     "ChlockBane-0.1",
+		ListenAddr:  *listen,
+		ChainParams: params,
+	}
+	if *keystore != "" {
+		cfg.KeystorePath = *keystore
+		cfg.KeystorePassword = os.Getenv(passwordEnv)
+	}
+
+	bootstrap := []string{}
+	if *peers != "" {
+		bootstrap = strings.Split(*peers, ",")
+	}
+	return node.NewNode(cfg).Start(*listen, bootstrap)
+}
+
+// parseValidators parses a comma separated list of hex public key:bond pairs.
+func parseValidators(s string) ([]*node.Validator, error) {
+	validators := []*node.Validator{}
+	if s == "" {
+		return validators, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		keyHex, bondStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("validator %q is not of the form public key:bond", entry)
+		}
+		b, err := hex.DecodeString(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("validator %q: %w", entry, err)
+		}
+		pubKey, err := crypto.ParsePublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("validator %q: %w", entry, err)
+		}
+		bond, err := strconv.ParseInt(bondStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("validator %q: %w", entry, err)
+		}
+		validators = append(validators, &node.Validator{PublicKey: pubKey, Bond: bond})
+	}
+	return validators, nil
+}
+
+func signMessage(args []string) error {
+	fs := flag.NewFlagSet("signmessage", flag.ExitOnError)
+	keystore := fs.String("keystore", "", "encrypted keystore file of the signing key, unlocked with $"+passwordEnv)
+	message := fs.String("message", "", "message to sign")
+	fs.Parse(args)
+
+	if *keystore == "" {
+		return fmt.Errorf("missing -keystore")
+	}
+	privKey, err := crypto.UnlockKeystore(*keystore, os.Getenv(passwordEnv))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("address:  ", privKey.Public().Address())
+	fmt.Println("signature:", crypto.SignMessage(privKey, []byte(*message)))
+	return nil
+}
+
+func verifyMessage(args []string) error {
+	fs := flag.NewFlagSet("verifymessage", flag.ExitOnError)
+	address := fs.String("address", "", "address that signed the message")
+	message := fs.String("message", "", "signed message")
+	signature := fs.String("signature", "", "signature from signmessage")
+	fs.Parse(args)
+
+	addr, err := crypto.DecodeAddress(*address, crypto.MainNet)
+	if err != nil {
+		return err
+	}
+	if err := crypto.VerifyMessage(addr, []byte(*message), *signature); err != nil {
+		return err
+	}
+
+	fmt.Println("signature is valid")
+	return nil
+}
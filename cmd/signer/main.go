@@ -0,0 +1,40 @@
+// Command signer runs a signer daemon holding a validator key, so the node can
+// sign blocks without the key in its own memory. Start the node with
+// ServerConfig.RemoteSignerPath set to the socket of the daemon.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/signer"
+)
+
+func main() {
+	var (
+		socket    = flag.String("socket", "signer.sock", "unix socket to serve on")
+		keystore  = flag.String("keystore", "", "encrypted keystore file holding the validator key")
+		statePath = flag.String("state", "signer_state.json", "file remembering the last signed block")
+	)
+	flag.Parse()
+
+	if *keystore == "" {
+		log.Fatal("missing -keystore")
+	}
+	privKey, err := crypto.UnlockKeystore(*keystore, os.Getenv("CHLOCKBANE_KEYSTORE_PASSWORD"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	local, err := signer.NewLocalSigner(privKey, *statePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("signing for %s on %s", privKey.Public().Address(), *socket)
+	if err := signer.NewServer(local).ListenAndServe(*socket); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Network identifies the chain an address is meant for. It is encoded into the
+// text form of addresses, so coins can't be sent to an address of another
+// network by mistake.
+type Network struct {
+	Name string
+	// Prefix is the human readable part of encoded addresses.
+	Prefix string
+	// Version is the first byte of the encoded address payload.
+	Version byte
+}
+
+var (
+	MainNet = &Network{Name: "mainnet", Prefix: "cb", Version: 0x00}
+	TestNet = &Network{Name: "testnet", Prefix: "tcb", Version: 0x6f}
+)
+
+var ErrWrongNetwork = errors.New("address belongs to a different network")
+
+type Address struct {
+	value []byte
+}
+
+func AddressFromBytes(b []byte) Address {
+	addr, err := ParseAddress(b)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// ParseAddress is like AddressFromBytes, but returns an error instead of
+// panicking on bytes of the wrong length.
+func ParseAddress(b []byte) (Address, error) {
+	if len(b) != AddressLen {
+		return Address{}, fmt.Errorf("%w: length %d, must be %d", ErrInvalidAddress, len(b), AddressLen)
+	}
+	return Address{
+		value: b,
+	}, nil
+}
+
+// hashAddress commits to data by taking the last AddressLen bytes of its
+// sha256 hash.
+func hashAddress(data ...[]byte) Address {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	sum := h.Sum(nil)
+	return Address{
+		value: sum[len(sum)-AddressLen:],
+	}
+}
+
+func (a Address) Bytes() []byte {
+	return a.value
+}
+
+// Hex returns the raw address bytes in hex, without network or checksum.
+func (a Address) Hex() string {
+	return hex.EncodeToString(a.value)
+}
+
+// Encode returns the checksummed bech32 encoding of the address on the given
+// network.
+func (a Address) Encode(net *Network) string {
+	data, err := convertBits(append([]byte{net.Version}, a.value...), 8, 5, true)
+	if err != nil {
+		panic(err)
+	}
+	return bech32Encode(net.Prefix, data)
+}
+
+// String returns the encoding of the address on MainNet.
+func (a Address) String() string {
+	return a.Encode(MainNet)
+}
+
+// DecodeAddress parses an address encoded for the given network. Addresses with
+// a bad checksum or of another network are rejected.
+func DecodeAddress(s string, net *Network) (Address, error) {
+	prefix, data, err := bech32Decode(s)
+	if err != nil {
+		return Address{}, err
+	}
+	if prefix != net.Prefix {
+		return Address{}, fmt.Errorf("%w: prefix %q, expected %q", ErrWrongNetwork, prefix, net.Prefix)
+	}
+
+	payload, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return Address{}, err
+	}
+	if len(payload) != AddressLen+1 {
+		return Address{}, fmt.Errorf("%w: length %d, must be %d", ErrInvalidAddress, len(payload)-1, AddressLen)
+	}
+	if payload[0] != net.Version {
+		return Address{}, fmt.Errorf("%w: version %d, expected %d", ErrWrongNetwork, payload[0], net.Version)
+	}
+	return Address{
+		value: payload[1:],
+	}, nil
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBech32Checksum(t *testing.T) {
+	// valid strings from BIP-173
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	} {
+		_, _, err := bech32Decode(s)
+		assert.Nil(t, err, s)
+	}
+
+	_, _, err := bech32Decode("a12UEL5L")
+	assert.NotNil(t, err)
+}
+
+func TestAddressEncoding(t *testing.T) {
+	address := GeneratePrivateKey().Public().Address()
+
+	for _, net := range []*Network{MainNet, TestNet} {
+		s := address.Encode(net)
+		assert.True(t, strings.HasPrefix(s, net.Prefix+"1"))
+
+		decoded, err := DecodeAddress(s, net)
+		require.Nil(t, err)
+		assert.Equal(t, address.Bytes(), decoded.Bytes())
+
+		decoded, err = DecodeAddress(strings.ToUpper(s), net)
+		require.Nil(t, err)
+		assert.Equal(t, address.Bytes(), decoded.Bytes())
+	}
+
+	_, err := DecodeAddress(address.Encode(TestNet), MainNet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	// same prefix, other version byte
+	other := &Network{Prefix: MainNet.Prefix, Version: TestNet.Version}
+	_, err = DecodeAddress(address.Encode(other), MainNet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+}
+
+func TestAddressTypo(t *testing.T) {
+	s := []byte(GeneratePrivateKey().Public().Address().String())
+	for i := len(MainNet.Prefix) + 1; i < len(s); i++ {
+		mistyped := make([]byte, len(s))
+		copy(mistyped, s)
+		if mistyped[i] == 'q' {
+			mistyped[i] = 'p'
+		} else {
+			mistyped[i] = 'q'
+		}
+		_, err := DecodeAddress(string(mistyped), MainNet)
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	}
+}
@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var ErrInvalidChecksum = errors.New("invalid checksum")
+
+func base58Encode(b []byte) string {
+	var (
+		n    = new(big.Int).SetBytes(b)
+		base = big.NewInt(58)
+		mod  = new(big.Int)
+		out  []byte
+	)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, v := range b {
+		if v != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	for _, c := range []byte(s) {
+		i := bytes.IndexByte([]byte(base58Alphabet), c)
+		if i < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	b := n.Bytes()
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), b...), nil
+}
+
+func checksum(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+// base58CheckEncode appends a 4 byte double SHA-256 checksum to b and encodes
+// the result in base58.
+func base58CheckEncode(b []byte) string {
+	return base58Encode(append(append([]byte{}, b...), checksum(b)...))
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	b, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 4 {
+		return nil, ErrInvalidChecksum
+	}
+	payload, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, ErrInvalidChecksum
+	}
+	return payload, nil
+}
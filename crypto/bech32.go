@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	bech32Charset     = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32ChecksumLen = 6
+	bech32MaxLen      = 90
+)
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, make([]byte, bech32ChecksumLen)...)
+	mod := bech32Polymod(values) ^ 1
+
+	out := make([]byte, bech32ChecksumLen)
+	for i := range out {
+		out[i] = byte(mod>>(5*(5-i))) & 31
+	}
+	return out
+}
+
+// bech32Encode encodes the 5 bit groups in data with the human readable part
+// and a checksum.
+func bech32Encode(hrp string, data []byte) string {
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range append(data, bech32Checksum(hrp, data)...) {
+		sb.WriteByte(bech32Charset[v])
+	}
+	return sb.String()
+}
+
+// bech32Decode returns the human readable part and the 5 bit groups of s after
+// verifying its checksum.
+func bech32Decode(s string) (string, []byte, error) {
+	if len(s) > bech32MaxLen {
+		return "", nil, fmt.Errorf("bech32 string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("bech32 string has mixed case")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+bech32ChecksumLen+1 > len(s) {
+		return "", nil, fmt.Errorf("invalid bech32 separator position")
+	}
+
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid bech32 prefix character %q", hrp[i])
+		}
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for _, c := range []byte(s[sep+1:]) {
+		i := strings.IndexByte(bech32Charset, c)
+		if i < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(i))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != 1 {
+		return "", nil, ErrInvalidChecksum
+	}
+	return hrp, data[:len(data)-bech32ChecksumLen], nil
+}
+
+// convertBits regroups the bits in data from groups of fromBits into groups of
+// toBits.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc    uint32
+		bits   uint
+		out    []byte
+		maxVal = uint32(1)<<toBits - 1
+	)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data value %d", v)
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxVal))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxVal))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxVal != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
@@ -0,0 +1,182 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// HardenedOffset is added to a child index to derive a hardened child. Ed25519
+// only supports hardened derivation.
+const HardenedOffset uint32 = 0x80000000
+
+const (
+	MinSeedLen = 16
+	MaxSeedLen = 64
+	// extendedKeyLen is the length of a serialized extended key, without
+	// checksum.
+	extendedKeyLen = 78
+)
+
+// extendedKeyVersion prefixes serialized extended private keys.
+var extendedKeyVersion = []byte{0x03, 0x1c, 0x8a, 0x6e}
+
+var ErrInvalidExtendedKey = errors.New("invalid extended key")
+
+// ExtendedKey is a private key together with the chain code needed to derive
+// its children, as specified by SLIP-0010 for ed25519.
+type ExtendedKey struct {
+	key               []byte
+	chainCode         []byte
+	depth             byte
+	parentFingerprint []byte
+	index             uint32
+}
+
+// NewMasterKey derives the root of the key tree from a seed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedLen || len(seed) > MaxSeedLen {
+		return nil, fmt.Errorf("invalid seed length %d, must be between %d and %d", len(seed), MinSeedLen, MaxSeedLen)
+	}
+
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	return &ExtendedKey{
+		key:               sum[:32],
+		chainCode:         sum[32:],
+		parentFingerprint: []byte{0, 0, 0, 0},
+	}, nil
+}
+
+// Child derives the child key at the given index, which must be hardened.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if index < HardenedOffset {
+		return nil, fmt.Errorf("ed25519 keys only support hardened derivation, got index %d", index)
+	}
+	if k.depth == 0xff {
+		return nil, fmt.Errorf("maximum derivation depth reached")
+	}
+
+	data := make([]byte, 0, 37)
+	data = append(data, 0x00)
+	data = append(data, k.key...)
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	return &ExtendedKey{
+		key:               sum[:32],
+		chainCode:         sum[32:],
+		depth:             k.depth + 1,
+		parentFingerprint: k.fingerprint(),
+		index:             index,
+	}, nil
+}
+
+// Derive follows a path like "m/44'/0'/1'" from this key. Every element of the
+// path must be hardened, marked by a trailing ' or h.
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q, must start with m", path)
+	}
+
+	key := k
+	for _, part := range parts[1:] {
+		if !strings.HasSuffix(part, "'") && !strings.HasSuffix(part, "h") {
+			return nil, fmt.Errorf("invalid path element %q, must be hardened", part)
+		}
+		index, err := strconv.ParseUint(part[:len(part)-1], 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path element %q: %w", part, err)
+		}
+		key, err = key.Child(uint32(index) + HardenedOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// DeriveKey derives the private key at path from the master key of the seed.
+func DeriveKey(seed []byte, path string) (*PrivateKey, error) {
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey(), nil
+}
+
+func (k *ExtendedKey) PrivateKey() *PrivateKey {
+	return NewPrivateKeyFromSeed(k.key)
+}
+
+func (k *ExtendedKey) ChainCode() []byte {
+	return k.chainCode
+}
+
+func (k *ExtendedKey) Depth() int {
+	return int(k.depth)
+}
+
+func (k *ExtendedKey) Index() uint32 {
+	return k.index
+}
+
+// fingerprint returns the first four bytes of the hash160 of the public key
+// with the 0x00 prefix SLIP-0010 gives ed25519 keys, so other wallets
+// recognize the parent of serialized keys.
+func (k *ExtendedKey) fingerprint() []byte {
+	sha := sha256.Sum256(append([]byte{0}, k.PrivateKey().Public().Bytes()...))
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+	return hasher.Sum(nil)[:4]
+}
+
+// String serializes the key in the BIP-32 layout, base58 encoded with a
+// checksum.
+func (k *ExtendedKey) String() string {
+	b := make([]byte, 0, extendedKeyLen)
+	b = append(b, extendedKeyVersion...)
+	b = append(b, k.depth)
+	b = append(b, k.parentFingerprint...)
+	b = binary.BigEndian.AppendUint32(b, k.index)
+	b = append(b, k.chainCode...)
+	b = append(b, 0x00)
+	b = append(b, k.key...)
+	return base58CheckEncode(b)
+}
+
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	b, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != extendedKeyLen || !bytes.Equal(b[:4], extendedKeyVersion) || b[45] != 0x00 {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	return &ExtendedKey{
+		depth:             b[4],
+		parentFingerprint: b[5:9],
+		index:             binary.BigEndian.Uint32(b[9:13]),
+		chainCode:         b[13:45],
+		key:               b[46:78],
+	}, nil
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vector 1 of SLIP-0010 for ed25519.
+func TestSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	vectors := []struct {
+		path string
+		// fingerprint of the parent key
+		fingerprint string
+		chainCode   string
+		key         string
+		publicKey   string
+	}{
+		{
+			path:        "m",
+			fingerprint: "00000000",
+			chainCode:   "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+			key:         "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+			publicKey:   "a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed",
+		},
+		{
+			path:        "m/0'",
+			fingerprint: "ddebc675",
+			chainCode:   "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69",
+			key:         "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			publicKey:   "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c",
+		},
+		{
+			path:        "m/0'/1'",
+			fingerprint: "13dab143",
+			chainCode:   "a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14",
+			key:         "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+			publicKey:   "1932a5270f335bed617d5b935c80aedb1a35bd9fc1e31acafd5372c30f5c1187",
+		},
+	}
+
+	master, err := NewMasterKey(seed)
+	require.Nil(t, err)
+
+	for _, v := range vectors {
+		key, err := master.Derive(v.path)
+		require.Nil(t, err)
+		assert.Equal(t, v.fingerprint, hex.EncodeToString(key.parentFingerprint), v.path)
+		assert.Equal(t, v.chainCode, hex.EncodeToString(key.ChainCode()), v.path)
+		assert.Equal(t, v.key, hex.EncodeToString(key.key), v.path)
+		assert.Equal(t, v.publicKey, hex.EncodeToString(key.PrivateKey().Public().Bytes()), v.path)
+	}
+}
+
+func TestDeriveRejectsNonHardenedPath(t *testing.T) {
+	master, err := NewMasterKey(make([]byte, 32))
+	require.Nil(t, err)
+
+	_, err = master.Derive("m/0")
+	assert.NotNil(t, err)
+	_, err = master.Child(1)
+	assert.NotNil(t, err)
+	_, err = master.Derive("44'/0'")
+	assert.NotNil(t, err)
+
+	_, err = NewMasterKey(make([]byte, 8))
+	assert.NotNil(t, err)
+}
+
+func TestExtendedKeySerialization(t *testing.T) {
+	master, err := NewMasterKey(make([]byte, 32))
+	require.Nil(t, err)
+	key, err := master.Derive("m/44'/1'")
+	require.Nil(t, err)
+
+	parsed, err := ParseExtendedKey(key.String())
+	require.Nil(t, err)
+	assert.Equal(t, key, parsed)
+	assert.Equal(t, 2, parsed.Depth())
+	assert.Equal(t, HardenedOffset+1, parsed.Index())
+
+	// a single changed character breaks the checksum
+	s := []byte(key.String())
+	if s[10] == 'a' {
+		s[10] = 'b'
+	} else {
+		s[10] = 'a'
+	}
+	_, err = ParseExtendedKey(string(s))
+	assert.NotNil(t, err)
+}
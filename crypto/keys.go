@@ -4,6 +4,8 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 )
 
@@ -15,6 +17,13 @@ const (
 	SigLen        = 64
 )
 
+var (
+	ErrInvalidSeed      = errors.New("invalid private key seed")
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidAddress   = errors.New("invalid address")
+)
+
 type PrivateKey struct {
 	key ed25519.PrivateKey
 }
@@ -37,13 +46,23 @@ func NewPrivateKeyFromSeedString(seed string) *PrivateKey {
 }
 
 func NewPrivateKeyFromSeed(seed []byte) *PrivateKey {
+	privKey, err := ParsePrivateKeySeed(seed)
+	if err != nil {
+		panic(err)
+	}
+	return privKey
+}
+
+// ParsePrivateKeySeed is like NewPrivateKeyFromSeed, but returns an error
+// instead of panicking on a seed of the wrong length.
+func ParsePrivateKeySeed(seed []byte) (*PrivateKey, error) {
 	if len(seed) != SeedLen {
-		panic("invalid seed length, must be 32")
+		return nil, fmt.Errorf("%w: length %d, must be %d", ErrInvalidSeed, len(seed), SeedLen)
 	}
 
 	return &PrivateKey{
 		key: ed25519.NewKeyFromSeed(seed),
-	}
+	}, nil
 }
 
 func GeneratePrivateKey() *PrivateKey {
@@ -61,6 +80,11 @@ func (p *PrivateKey) Bytes() []byte {
 	return p.key
 }
 
+// Seed returns the 32 byte seed the key was created from.
+func (p *PrivateKey) Seed() []byte {
+	return p.key.Seed()
+}
+
 func (p *PrivateKey) Sign(msg []byte) *Signature {
 	return &Signature{
 		value: ed25519.Sign(p.key, msg),
@@ -81,18 +105,27 @@ type PublicKey struct {
 }
 
 func PublicKeyFromBytes(b []byte) *PublicKey {
+	pubKey, err := ParsePublicKey(b)
+	if err != nil {
+		panic(err)
+	}
+	return pubKey
+}
+
+// ParsePublicKey is like PublicKeyFromBytes, but returns an error instead of
+// panicking on bytes of the wrong length. Use it for keys received from peers.
+func ParsePublicKey(b []byte) (*PublicKey, error) {
 	if len(b) != PublicKeyLen {
-		panic("invalid public key bytes length. should be 32")
+		return nil, fmt.Errorf("%w: length %d, must be %d", ErrInvalidPublicKey, len(b), PublicKeyLen)
 	}
 	return &PublicKey{
 		key: ed25519.PublicKey(b),
-	}
+	}, nil
 }
 
+// Address returns the address of the key, a hash committing to the key.
 func (p *PublicKey) Address() Address {
-	return Address{
-		value: p.key[len(p.key)-AddressLen:],
-	}
+	return hashAddress(p.key)
 }
 
 func (p *PublicKey) Bytes() []byte {
@@ -104,12 +137,23 @@ type Signature struct {
 }
 
 func SignatureFromBytes(b []byte) *Signature {
+	sig, err := ParseSignature(b)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// ParseSignature is like SignatureFromBytes, but returns an error instead of
+// panicking on bytes of the wrong length. Use it for signatures received from
+// peers.
+func ParseSignature(b []byte) (*Signature, error) {
 	if len(b) != SigLen {
-		panic("invalid signature bytes length. should be 64")
+		return nil, fmt.Errorf("%w: length %d, must be %d", ErrInvalidSignature, len(b), SigLen)
 	}
 	return &Signature{
 		value: b,
-	}
+	}, nil
 }
 
 func (s *Signature) Bytes() []byte {
@@ -119,24 +163,3 @@ func (s *Signature) Bytes() []byte {
 func (s *Signature) Verify(pubKey *PublicKey, msg []byte) bool {
 	return ed25519.Verify(pubKey.key, msg, s.value)
 }
-
-type Address struct {
-	value []byte
-}
-
-func AddressFromBytes(b []byte) Address {
-	if len(b) != AddressLen {
-		panic("length of the bytes not equal to 20")
-	}
-	return Address{
-		value: b,
-	}
-}
-
-func (a Address) Bytes() []byte {
-	return a.value
-}
-
-func (a Address) String() string {
-	return hex.EncodeToString(a.value)
-}
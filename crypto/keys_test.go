@@ -40,10 +40,31 @@ func TestNewPrivateKeyFromString(t *testing.T) {
 	var (
 		seed       = "58618bc746c4c3b20211878cdae7a47041f7f031f4eadefdc399d25700939b95"
 		privKey    = NewPrivateKeyFromString(seed)
-		addressStr = "a808d31c6c3f810be84d70da3b55d6d7c4505aa1"
+		addressHex = "10cb10621f26710a4a73517a693046ebdc5fc4e1"
+		addressStr = "cb1qqgvkyrzrun8zzj2wdgh56fsgm4ach7yuycs3gc0"
 	)
 
 	assert.Equal(t, PrivateKeyLen, len(privKey.Bytes()))
 	address := privKey.Public().Address()
+	assert.Equal(t, addressHex, address.Hex())
 	assert.Equal(t, addressStr, address.String())
 }
+
+func TestParseMalformedBytes(t *testing.T) {
+	_, err := ParsePublicKey(make([]byte, PublicKeyLen-1))
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+
+	_, err = ParseSignature(nil)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = ParseAddress(make([]byte, AddressLen+1))
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+
+	_, err = ParsePrivateKeySeed(make([]byte, 10))
+	assert.ErrorIs(t, err, ErrInvalidSeed)
+
+	pubKey := GeneratePrivateKey().Public()
+	parsed, err := ParsePublicKey(pubKey.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, pubKey.Bytes(), parsed.Bytes())
+}
@@ -0,0 +1,242 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "scrypt"
+	keystoreCipher  = "aes-256-gcm"
+
+	// StandardScryptN is the scrypt cost used for keys stored on disk.
+	StandardScryptN = 1 << 18
+	// LightScryptN is a cheaper scrypt cost, for tests and low end devices.
+	LightScryptN = 1 << 12
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 32
+
+	// the largest scrypt parameters a keystore may ask for. With N and r
+	// at their limits scrypt needs 1 GiB of memory.
+	maxScryptN = 1 << 20
+	maxScryptR = 8
+	maxScryptP = 4
+)
+
+var ErrWrongPassword = errors.New("could not decrypt key with given password")
+
+// keystoreJSON is the on disk format of an encrypted key. The seed of the key
+// is encrypted with AES-256-GCM under a key derived from the password with
+// scrypt. The address is authenticated as additional data.
+type keystoreJSON struct {
+	Version int            `json:"version"`
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+type keystoreCrypto struct {
+	KDF        string       `json:"kdf"`
+	KDFParams  scryptParams `json:"kdfparams"`
+	Cipher     string       `json:"cipher"`
+	Nonce      string       `json:"nonce"`
+	Ciphertext string       `json:"ciphertext"`
+}
+
+type scryptParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// EncryptKey returns the keystore JSON of the key encrypted with the password.
+func EncryptKey(key *PrivateKey, password string, scryptN int) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	params := scryptParams{
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+		Salt: hex.EncodeToString(salt),
+	}
+
+	aead, err := keystoreAEAD(password, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	address := key.Public().Address().String()
+	ciphertext := aead.Seal(nil, nonce, key.Seed(), []byte(address))
+
+	return json.MarshalIndent(keystoreJSON{
+		Version: keystoreVersion,
+		Address: address,
+		Crypto: keystoreCrypto{
+			KDF:        keystoreKDF,
+			KDFParams:  params,
+			Cipher:     keystoreCipher,
+			Nonce:      hex.EncodeToString(nonce),
+			Ciphertext: hex.EncodeToString(ciphertext),
+		},
+	}, "", "  ")
+}
+
+// DecryptKey decrypts keystore JSON created by EncryptKey.
+func DecryptKey(data []byte, password string) (*PrivateKey, error) {
+	ks, err := parseKeystore(data)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := keystoreAEAD(password, ks.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(ks.Crypto.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid keystore nonce")
+	}
+	ciphertext, err := hex.DecodeString(ks.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore ciphertext")
+	}
+
+	seed, err := aead.Open(nil, nonce, ciphertext, []byte(ks.Address))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	if len(seed) != SeedLen {
+		return nil, fmt.Errorf("invalid keystore seed length %d", len(seed))
+	}
+
+	key := NewPrivateKeyFromSeed(seed)
+	if key.Public().Address().String() != ks.Address {
+		return nil, fmt.Errorf("keystore key does not match address %s", ks.Address)
+	}
+	return key, nil
+}
+
+func parseKeystore(data []byte) (*keystoreJSON, error) {
+	ks := &keystoreJSON{}
+	if err := json.Unmarshal(data, ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore: %w", err)
+	}
+	if ks.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	if ks.Crypto.KDF != keystoreKDF || ks.Crypto.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported keystore kdf %q or cipher %q", ks.Crypto.KDF, ks.Crypto.Cipher)
+	}
+	return ks, nil
+}
+
+func keystoreAEAD(password string, params scryptParams) (cipher.AEAD, error) {
+	// the parameters come from the file, so a bad one must not make us spend
+	// unbounded memory or time
+	if params.N < 2 || params.N > maxScryptN || params.N&(params.N-1) != 0 {
+		return nil, fmt.Errorf("invalid keystore scrypt N %d", params.N)
+	}
+	if params.R < 1 || params.R > maxScryptR {
+		return nil, fmt.Errorf("invalid keystore scrypt r %d", params.R)
+	}
+	if params.P < 1 || params.P > maxScryptP {
+		return nil, fmt.Errorf("invalid keystore scrypt p %d", params.P)
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt")
+	}
+	derived, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// CreateKeystore encrypts the key with the password and writes it to a new file
+// at path, readable by the owner only.
+func CreateKeystore(path string, key *PrivateKey, password string, scryptN int) error {
+	data, err := EncryptKey(key, password, scryptN)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// UnlockKeystore reads the keystore file at path and decrypts its key.
+func UnlockKeystore(path string, password string) (*PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKey(data, password)
+}
+
+// ChangeKeystorePassword re-encrypts the keystore file at path under a new
+// password, keeping its scrypt cost. The file is replaced atomically.
+func ChangeKeystorePassword(path string, oldPassword, newPassword string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ks, err := parseKeystore(data)
+	if err != nil {
+		return err
+	}
+	key, err := DecryptKey(data, oldPassword)
+	if err != nil {
+		return err
+	}
+
+	data, err = EncryptKey(key, newPassword, ks.Crypto.KDFParams.N)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptKey(t *testing.T) {
+	key := GeneratePrivateKey()
+
+	data, err := EncryptKey(key, "hunter2", LightScryptN)
+	require.Nil(t, err)
+	assert.NotContains(t, string(data), string(key.Seed()))
+
+	decrypted, err := DecryptKey(data, "hunter2")
+	require.Nil(t, err)
+	assert.Equal(t, key.Bytes(), decrypted.Bytes())
+
+	_, err = DecryptKey(data, "hunter3")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+}
+
+func TestKeystoreFile(t *testing.T) {
+	var (
+		key  = GeneratePrivateKey()
+		path = filepath.Join(t.TempDir(), "validator.json")
+	)
+
+	require.Nil(t, CreateKeystore(path, key, "old", LightScryptN))
+	// never overwrite an existing keystore
+	require.NotNil(t, CreateKeystore(path, GeneratePrivateKey(), "old", LightScryptN))
+
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NotNil(t, ChangeKeystorePassword(path, "wrong", "new"))
+	require.Nil(t, ChangeKeystorePassword(path, "old", "new"))
+
+	_, err = UnlockKeystore(path, "old")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	unlocked, err := UnlockKeystore(path, "new")
+	require.Nil(t, err)
+	assert.Equal(t, key.Bytes(), unlocked.Bytes())
+}
+
+func TestKeystoreScryptLimits(t *testing.T) {
+	data, err := EncryptKey(GeneratePrivateKey(), "hunter2", LightScryptN)
+	require.Nil(t, err)
+
+	tests := []scryptParams{
+		{N: 1 << 30, R: scryptR, P: scryptP},
+		{N: 3000, R: scryptR, P: scryptP},
+		{N: 0, R: scryptR, P: scryptP},
+		{N: LightScryptN, R: 1 << 20, P: scryptP},
+		{N: LightScryptN, R: scryptR, P: 1 << 20},
+		{N: LightScryptN, R: 0, P: scryptP},
+	}
+	for _, params := range tests {
+		var ks keystoreJSON
+		require.Nil(t, json.Unmarshal(data, &ks))
+		params.Salt = ks.Crypto.KDFParams.Salt
+		ks.Crypto.KDFParams = params
+		tampered, err := json.Marshal(ks)
+		require.Nil(t, err)
+
+		_, err = DecryptKey(tampered, "hunter2")
+		assert.NotNil(t, err)
+		assert.NotErrorIs(t, err, ErrWrongPassword)
+	}
+
+	_, err = EncryptKey(GeneratePrivateKey(), "hunter2", maxScryptN*2)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// messagePrefix separates signed messages from everything else the keys sign.
+// Transactions and blocks are signed over hashes of protobuf encodings, which
+// never start with this prefix, so a message signature can't be replayed as a
+// transaction or block signature.
+const messagePrefix = "ChlockBane Signed Message:\n"
+
+var ErrInvalidMessageSignature = errors.New("invalid message signature")
+
+// HashMessage returns the hash signed by SignMessage: the prefix, the length of
+// the message and the message itself.
+func HashMessage(msg []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(messagePrefix))
+	h.Write(binary.AppendUvarint(nil, uint64(len(msg))))
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// SignMessage signs an arbitrary message, proving control of the address of
+// the key. The result holds the public key and the signature, encoded with
+// base58check.
+func SignMessage(privKey *PrivateKey, msg []byte) string {
+	sig := privKey.Sign(HashMessage(msg))
+	return base58CheckEncode(append(privKey.Public().Bytes(), sig.Bytes()...))
+}
+
+// VerifyMessage checks that the signature created by SignMessage signs the
+// message with the key behind the address.
+func VerifyMessage(addr Address, msg []byte, signature string) error {
+	b, err := base58CheckDecode(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessageSignature, err)
+	}
+	if len(b) != PublicKeyLen+SigLen {
+		return fmt.Errorf("%w: length %d", ErrInvalidMessageSignature, len(b))
+	}
+
+	pubKey, err := ParsePublicKey(b[:PublicKeyLen])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pubKey.Address().Bytes(), addr.Bytes()) {
+		return fmt.Errorf("%w: signed by %s, not %s", ErrInvalidMessageSignature, pubKey.Address(), addr)
+	}
+
+	sig, err := ParseSignature(b[PublicKeyLen:])
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(pubKey, HashMessage(msg)) {
+		return ErrInvalidMessageSignature
+	}
+	return nil
+}
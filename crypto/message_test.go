@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignMessage(t *testing.T) {
+	var (
+		privKey = GeneratePrivateKey()
+		addr    = privKey.Public().Address()
+		msg     = []byte("I control this address")
+	)
+
+	sig := SignMessage(privKey, msg)
+	require.Nil(t, VerifyMessage(addr, msg, sig))
+
+	assert.ErrorIs(t, VerifyMessage(addr, []byte("I control another address"), sig), ErrInvalidMessageSignature)
+	assert.ErrorIs(t, VerifyMessage(GeneratePrivateKey().Public().Address(), msg, sig), ErrInvalidMessageSignature)
+	assert.ErrorIs(t, VerifyMessage(addr, msg, sig[:len(sig)-1]+"1"), ErrInvalidMessageSignature)
+	assert.ErrorIs(t, VerifyMessage(addr, msg, "abc"), ErrInvalidMessageSignature)
+}
+
+func TestMessageSignatureIsDomainSeparated(t *testing.T) {
+	var (
+		privKey = GeneratePrivateKey()
+		msg     = []byte("hash of something else")
+	)
+
+	// a signature over the raw bytes, as used for transactions and blocks,
+	// doesn't verify as a message signature
+	raw := base58CheckEncode(append(privKey.Public().Bytes(), privKey.Sign(msg).Bytes()...))
+	assert.ErrorIs(t, VerifyMessage(privKey.Public().Address(), msg, raw), ErrInvalidMessageSignature)
+
+	sig, err := base58CheckDecode(SignMessage(privKey, msg))
+	require.Nil(t, err)
+	assert.False(t, SignatureFromBytes(sig[PublicKeyLen:]).Verify(privKey.Public(), msg))
+}
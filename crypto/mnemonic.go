@@ -0,0 +1,141 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// The BIP-39 English wordlist.
+//
+//go:embed wordlists/english.txt
+var englishWordlist string
+
+// mnemonicIterations is the PBKDF2 iteration count of the seed derivation.
+const mnemonicIterations = 2048
+
+var (
+	wordlist  = strings.Split(strings.TrimSpace(englishWordlist), "\n")
+	wordIndex = make(map[string]int, len(wordlist))
+)
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+func init() {
+	for i, w := range wordlist {
+		wordIndex[w] = i
+	}
+}
+
+// NewMnemonic generates a mnemonic from bits of random entropy. bits must be a
+// multiple of 32 between 128 and 256, resulting in 12 to 24 words.
+func NewMnemonic(bits int) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy size %d, must be a multiple of 32 between 128 and 256", bits)
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := io.ReadFull(rand.Reader, entropy); err != nil {
+		return "", err
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy encodes the entropy and its checksum as words, 11 bits
+// per word.
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy length %d", len(entropy))
+	}
+
+	var (
+		checksumBits = bits / 32
+		hash         = sha256.Sum256(entropy)
+		n            = new(big.Int).SetBytes(entropy)
+	)
+	n.Lsh(n, uint(checksumBits))
+	n.Or(n, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	nWords := (bits + checksumBits) / 11
+	words := make([]string, nWords)
+	mask := big.NewInt(2047)
+	for i := nWords - 1; i >= 0; i-- {
+		words[i] = wordlist[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes the mnemonic and verifies its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("%w: %d words", ErrInvalidMnemonic, len(words))
+	}
+
+	n := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(i)))
+	}
+
+	var (
+		checksumBits = len(words) * 11 / 33
+		checksum     = new(big.Int).And(n, big.NewInt(int64(1)<<checksumBits-1)).Int64()
+		entropy      = make([]byte, (len(words)*11-checksumBits)/8)
+	)
+	n.Rsh(n, uint(checksumBits))
+	n.FillBytes(entropy)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed turns a valid mnemonic and an optional passphrase into the 64
+// byte seed of an HD key tree. Every passphrase results in a different seed.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	var (
+		password = norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+		salt     = norm.NFKD.String("mnemonic" + passphrase)
+	)
+	return pbkdf2.Key([]byte(password), []byte(salt), mnemonicIterations, 64, sha512.New), nil
+}
+
+// NewPrivateKeyFromMnemonic returns the master key of the key tree of the
+// mnemonic and passphrase.
+func NewPrivateKeyFromMnemonic(mnemonic, passphrase string) (*PrivateKey, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return master.PrivateKey(), nil
+}
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from the BIP-39 reference implementation, with passphrase
+// "TREZOR".
+func TestMnemonicVectors(t *testing.T) {
+	vectors := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+			seed:     "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+		},
+	}
+
+	for _, v := range vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		mnemonic, err := MnemonicFromEntropy(entropy)
+		require.Nil(t, err)
+		assert.Equal(t, v.mnemonic, mnemonic)
+
+		decoded, err := MnemonicToEntropy(mnemonic)
+		require.Nil(t, err)
+		assert.Equal(t, entropy, decoded)
+
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		require.Nil(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		require.Nil(t, err)
+		assert.Equal(t, (bits+bits/32)/11, len(strings.Fields(mnemonic)))
+		assert.Nil(t, ValidateMnemonic(mnemonic))
+	}
+
+	_, err := NewMnemonic(100)
+	assert.NotNil(t, err)
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	// wrong checksum word
+	assert.ErrorIs(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"), ErrInvalidMnemonic)
+	// unknown word
+	assert.ErrorIs(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abuot"), ErrInvalidMnemonic)
+	// wrong number of words
+	assert.ErrorIs(t, ValidateMnemonic("abandon abandon about"), ErrInvalidMnemonic)
+}
+
+func TestNewPrivateKeyFromMnemonic(t *testing.T) {
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+	a, err := NewPrivateKeyFromMnemonic(mnemonic, "")
+	require.Nil(t, err)
+	b, err := NewPrivateKeyFromMnemonic(mnemonic, "")
+	require.Nil(t, err)
+	c, err := NewPrivateKeyFromMnemonic(mnemonic, "passphrase")
+	require.Nil(t, err)
+
+	assert.Equal(t, a.Bytes(), b.Bytes())
+	assert.NotEqual(t, a.Bytes(), c.Bytes())
+}
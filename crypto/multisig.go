@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// MaxMultisigKeys is the largest number of keys an M-of-N lock may list.
+const MaxMultisigKeys = 16
+
+// SortPublicKeys sorts the keys by their bytes, which is the canonical order of
+// the keys in a multisig lock.
+func SortPublicKeys(keys []*PublicKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].key, keys[j].key) < 0
+	})
+}
+
+// MultisigAddress returns the address of a lock requiring threshold of the
+// given keys. The keys are sorted first, so every ordering of the same keys
+// results in the same address.
+func MultisigAddress(threshold int, keys []*PublicKey) Address {
+	if len(keys) == 0 || len(keys) > MaxMultisigKeys {
+		panic(fmt.Sprintf("invalid number of multisig keys %d", len(keys)))
+	}
+	if threshold < 1 || threshold > len(keys) {
+		panic(fmt.Sprintf("invalid multisig threshold %d of %d", threshold, len(keys)))
+	}
+
+	sorted := make([]*PublicKey, len(keys))
+	copy(sorted, keys)
+	SortPublicKeys(sorted)
+
+	data := [][]byte{{byte(threshold), byte(len(sorted))}}
+	for _, k := range sorted {
+		data = append(data, k.key)
+	}
+	return hashAddress(data...)
+}
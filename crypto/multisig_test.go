@@ -0,0 +1,24 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultisigAddress(t *testing.T) {
+	var (
+		a = GeneratePrivateKey().Public()
+		b = GeneratePrivateKey().Public()
+		c = GeneratePrivateKey().Public()
+	)
+
+	addr := MultisigAddress(2, []*PublicKey{a, b, c})
+	assert.Equal(t, AddressLen, len(addr.Bytes()))
+	assert.Equal(t, addr, MultisigAddress(2, []*PublicKey{c, a, b}))
+	assert.NotEqual(t, addr, MultisigAddress(3, []*PublicKey{a, b, c}))
+	assert.NotEqual(t, addr, MultisigAddress(2, []*PublicKey{a, b}))
+
+	assert.Panics(t, func() { MultisigAddress(0, []*PublicKey{a}) })
+	assert.Panics(t, func() { MultisigAddress(2, []*PublicKey{a}) })
+}
@@ -3,21 +3,25 @@ module github.com/mhg14/ChlockBane
 go 1.20
 
 require (
-	github.com/cbergoon/merkletree v0.2.0 // indirect
+	github.com/cbergoon/merkletree v0.2.0
+	github.com/stretchr/testify v1.8.4
+	go.uber.org/zap v1.24.0
+	golang.org/x/crypto v0.10.0
+	golang.org/x/text v0.10.0
+	google.golang.org/grpc v1.56.1
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
-	go.uber.org/zap v1.24.0 // indirect
 	golang.org/x/net v0.11.0 // indirect
 	golang.org/x/sys v0.9.0 // indirect
-	golang.org/x/text v0.10.0 // indirect
 	google.golang.org/genproto v0.0.0-20230525234025-438c736192d0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230629202037-9506855d4529 // indirect
-	google.golang.org/grpc v1.56.1 // indirect
-	google.golang.org/protobuf v1.31.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -4,22 +4,37 @@ import (
 	"context"
 
 	"log"
+	"os"
 	"time"
 
 	"github.com/mhg14/ChlockBane/crypto"
 	"github.com/mhg14/ChlockBane/node"
 	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
 	"github.com/mhg14/ChlockBane/util"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	makeNode(":3000", []string{}, true)
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// all nodes have to agree on the validator set, or they reject the
+	// blocks of the validator
+	validatorKey := crypto.GeneratePrivateKey()
+	params := node.DefaultChainParams()
+	params.GenesisValidators = []*node.Validator{{PublicKey: validatorKey.Public(), Bond: 100}}
+
+	makeNode(":3000", []string{}, params, validatorKey)
 	time.Sleep(time.Second)
-	makeNode(":4000", []string{":3000"}, false)
+	makeNode(":4000", []string{":3000"}, params, nil)
 	time.Sleep(4 * time.Second)
-	makeNode(":5000", []string{":4000"}, false)
+	makeNode(":5000", []string{":4000"}, params, nil)
 
 	for {
 		time.Sleep(time.Second)
@@ -27,13 +42,14 @@ func main() {
 	}
 }
 
-func makeNode(listenAddr string, bootstrapNodes []string, isValidator bool) *node.Node {
+// makeNode starts a node with the given chain params. Nodes given a private key
+// are validators.
+func makeNode(listenAddr string, bootstrapNodes []string, params *node.ChainParams, privKey *crypto.PrivateKey) *node.Node {
 	cfg := node.ServerConfig{
-		Version:    "ChlockBane-0.1",
-		ListenAddr: listenAddr,
-	}
-	if isValidator {
-		cfg.PrivateKey = crypto.GeneratePrivateKey()
+		Version:     "ChlockBane-0.1",
+		ListenAddr:  listenAddr,
+		PrivateKey:  privKey,
+		ChainParams: params,
 	}
 	n := node.NewNode(cfg)
 	go n.Start(listenAddr, bootstrapNodes)
@@ -62,9 +78,11 @@ func makeTransaction() {
 			Address: pubKey.Address().Bytes(),
 		}},
 	}
+	tx.Inputs[0].Signature = types.SignTransaction(privKey, tx).Bytes()
 
+	// the input spends a random output, so the node is expected to reject it
 	_, err = c.HandleTransaction(context.TODO(), tx, grpc.EmptyCallOption{})
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 	}
 }
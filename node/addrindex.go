@@ -0,0 +1,195 @@
+package node
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// ErrAddressIndexDisabled is returned by address queries on a chain that
+// doesn't maintain the address index.
+var ErrAddressIndexDisabled = errors.New("address index is not enabled")
+
+// AddressTx is a transaction in the history of an address, one that paid to
+// the address or spent one of its outputs.
+type AddressTx struct {
+	TxHash []byte
+	Height int
+}
+
+// outputOwners returns the addresses an output is indexed under. Outputs are
+// indexed under their address or the address of their standard lock script,
+// HTLC outputs under sender and recipient, and channel outputs under payer and
+// payee.
+func outputOwners(output *proto.TxOutput) [][]byte {
+	switch {
+	case output.Htlc != nil:
+		return [][]byte{output.Htlc.Recipient, output.Htlc.Sender}
+	case output.Channel != nil:
+		owners := [][]byte{}
+		for _, key := range [][]byte{output.Channel.Payer, output.Channel.Payee} {
+			if pubKey, err := crypto.ParsePublicKey(key); err == nil {
+				owners = append(owners, pubKey.Address().Bytes())
+			}
+		}
+		return owners
+	case len(output.Address) > 0:
+		return [][]byte{output.Address}
+	}
+	if addr, ok := script.ExtractAddress(output.LockScript); ok {
+		return [][]byte{addr.Bytes()}
+	}
+	return nil
+}
+
+// addressIndex maps hex encoded addresses to the keys of the outputs paying
+// to them and to the transactions touching them, both in chain order.
+type addressIndex struct {
+	outputs map[string][]string
+	history map[string][]*AddressTx
+}
+
+// EnableAddressIndex makes the chain maintain an index of the outputs and
+// transactions of every address. The index is built from the blocks already
+// connected, and kept up to date as blocks are connected and disconnected.
+func (c *Chain) EnableAddressIndex() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.addrIndex != nil {
+		return nil
+	}
+	c.addrIndex = &addressIndex{
+		outputs: make(map[string][]string),
+		history: make(map[string][]*AddressTx),
+	}
+	for height := 0; height <= c.headers.Height(); height++ {
+		b, err := c.getBlockByHeight(height)
+		if err != nil {
+			c.addrIndex = nil
+			return err
+		}
+		for _, tx := range b.Transactions {
+			if err := c.indexAddresses(c.undoLog[height], tx, height); err != nil {
+				c.addrIndex = nil
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// indexAddresses adds the outputs of the transaction to the index, and the
+// transaction to the history of every address it pays to or spends from.
+func (c *Chain) indexAddresses(undo *blockUndo, tx *proto.Transaction, height int) error {
+	txHash := types.HashTransaction(tx)
+	hash := hex.EncodeToString(txHash)
+	touched := []string{}
+	seen := map[string]bool{}
+	touch := func(address []byte) {
+		key := hex.EncodeToString(address)
+		if !seen[key] {
+			seen[key] = true
+			touched = append(touched, key)
+		}
+	}
+
+	for _, input := range tx.Inputs {
+		key := fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+		utxo, err := c.utxoStore.Get(key)
+		if err != nil {
+			return err
+		}
+		for _, owner := range outputOwners(utxo.Unspent().Output) {
+			touch(owner)
+		}
+	}
+
+	for it, output := range tx.Outputs {
+		if types.IsDataOutput(output) {
+			continue
+		}
+		key := fmt.Sprintf("%s_%d", hash, it)
+		for _, owner := range outputOwners(output) {
+			touch(owner)
+			address := hex.EncodeToString(owner)
+			c.addrIndex.outputs[address] = append(c.addrIndex.outputs[address], key)
+			undo.add(func() error {
+				outputs := c.addrIndex.outputs[address]
+				c.addrIndex.outputs[address] = outputs[:len(outputs)-1]
+				return nil
+			})
+		}
+	}
+
+	for _, address := range touched {
+		address := address
+		c.addrIndex.history[address] = append(c.addrIndex.history[address], &AddressTx{
+			TxHash: txHash,
+			Height: height,
+		})
+		undo.add(func() error {
+			history := c.addrIndex.history[address]
+			c.addrIndex.history[address] = history[:len(history)-1]
+			return nil
+		})
+	}
+	return nil
+}
+
+// ListUnspent returns the unspent outputs paying to the address, including the
+// HTLC and channel outputs it is a party of.
+func (c *Chain) ListUnspent(address []byte) ([]*UTXO, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.addrIndex == nil {
+		return nil, ErrAddressIndexDisabled
+	}
+	unspent := []*UTXO{}
+	for _, key := range c.addrIndex.outputs[hex.EncodeToString(address)] {
+		utxo, err := c.utxoStore.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !utxo.Spent {
+			unspent = append(unspent, utxo)
+		}
+	}
+	return unspent, nil
+}
+
+// GetBalance returns the amount of the native coin in the unspent outputs
+// paying to the address. HTLC and channel outputs are left out, since their
+// amount goes to one of the parties only when the contract settles.
+func (c *Chain) GetBalance(address []byte) (int64, error) {
+	unspent, err := c.ListUnspent(address)
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	for _, utxo := range unspent {
+		if len(utxo.Asset) == 0 && utxo.HTLC == nil && utxo.Channel == nil {
+			balance += utxo.Amount
+		}
+	}
+	return balance, nil
+}
+
+// GetAddressHistory returns every transaction that paid to the address or
+// spent one of its outputs, oldest first.
+func (c *Chain) GetAddressHistory(address []byte) ([]*AddressTx, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.addrIndex == nil {
+		return nil, ErrAddressIndexDisabled
+	}
+	history := c.addrIndex.history[hex.EncodeToString(address)]
+	return append([]*AddressTx{}, history...), nil
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// payAlice funds alice from the genesis output, then has her pay bob part of
+// it, returning both transactions.
+func payAlice(t *testing.T, chain *Chain, alice, bob *crypto.PrivateKey) (*proto.Transaction, *proto.Transaction) {
+	fundTx := genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()})
+	addBlockWithTx(t, chain, fundTx)
+
+	payTx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash: types.HashTransaction(fundTx),
+			PublicKey:  alice.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{
+			{Amount: 300, Address: bob.Public().Address().Bytes()},
+			{Amount: 700, Address: alice.Public().Address().Bytes()},
+		},
+	}
+	payTx.Inputs[0].Signature = types.SignTransaction(alice, payTx).Bytes()
+	addBlockWithTx(t, chain, payTx)
+	return fundTx, payTx
+}
+
+func TestAddressIndex(t *testing.T) {
+	var (
+		chain = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		alice = crypto.GeneratePrivateKey()
+		bob   = crypto.GeneratePrivateKey()
+	)
+	require.Nil(t, chain.EnableAddressIndex())
+
+	fundTx, payTx := payAlice(t, chain, alice, bob)
+	aliceAddr := alice.Public().Address().Bytes()
+
+	balance, err := chain.GetBalance(aliceAddr)
+	require.Nil(t, err)
+	assert.Equal(t, int64(700), balance)
+	balance, err = chain.GetBalance(bob.Public().Address().Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, int64(300), balance)
+
+	unspent, err := chain.ListUnspent(aliceAddr)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(unspent))
+	assert.Equal(t, 1, unspent[0].OutIndex)
+	assert.Equal(t, 2, unspent[0].Height)
+
+	history, err := chain.GetAddressHistory(aliceAddr)
+	require.Nil(t, err)
+	assert.Equal(t, []*AddressTx{
+		{TxHash: types.HashTransaction(fundTx), Height: 1},
+		{TxHash: types.HashTransaction(payTx), Height: 2},
+	}, history)
+
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+
+	balance, err = chain.GetBalance(aliceAddr)
+	require.Nil(t, err)
+	assert.Equal(t, int64(1000), balance)
+	balance, err = chain.GetBalance(bob.Public().Address().Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), balance)
+	history, err = chain.GetAddressHistory(aliceAddr)
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(history))
+}
+
+func TestEnableAddressIndexOnExistingChain(t *testing.T) {
+	var (
+		chain = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		alice = crypto.GeneratePrivateKey()
+		bob   = crypto.GeneratePrivateKey()
+	)
+
+	_, err := chain.GetBalance(alice.Public().Address().Bytes())
+	require.ErrorIs(t, err, ErrAddressIndexDisabled)
+
+	payAlice(t, chain, alice, bob)
+	require.Nil(t, chain.EnableAddressIndex())
+
+	balance, err := chain.GetBalance(alice.Public().Address().Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, int64(700), balance)
+
+	// blocks connected before the index was enabled are reverted from it too
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	history, err := chain.GetAddressHistory(alice.Public().Address().Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(history))
+}
+
+func TestAddressQueriesRPC(t *testing.T) {
+	var (
+		node  = NewNode(ServerConfig{AddressIndex: true})
+		alice = crypto.GeneratePrivateKey()
+		bob   = crypto.GeneratePrivateKey()
+		req   = &proto.AddressRequest{Address: bob.Public().Address().Bytes()}
+	)
+	_, payTx := payAlice(t, node.chain, alice, bob)
+
+	resp, err := NewQueryServer(node).ListUnspent(context.Background(), req)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(resp.Outputs))
+	assert.Equal(t, types.HashTransaction(payTx), resp.Outputs[0].TxHash)
+	assert.Equal(t, payTx.Outputs[0].Amount, resp.Outputs[0].Output.Amount)
+
+	_, err = NewQueryServer(NewNode(ServerConfig{})).GetBalance(context.Background(), req)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestAddressIndexLockedOutputs(t *testing.T) {
+	var (
+		chain     = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		alice     = crypto.GeneratePrivateKey()
+		bob       = crypto.GeneratePrivateKey()
+		aliceAddr = alice.Public().Address()
+		bobAddr   = bob.Public().Address()
+	)
+	require.Nil(t, chain.EnableAddressIndex())
+
+	hash := sha256.Sum256(util.RandomHash())
+	tx := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 100, LockScript: script.PayToAddress(aliceAddr)},
+		types.NewHTLCOutput(200, hash[:], bobAddr, aliceAddr, 10),
+		types.NewChannelOutput(300, alice.Public(), bob.Public(), 10),
+	)
+	addBlockWithTx(t, chain, tx)
+
+	unspent, err := chain.ListUnspent(aliceAddr.Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, 3, len(unspent))
+	unspent, err = chain.ListUnspent(bobAddr.Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(unspent))
+
+	// only the script output belongs to alice alone
+	balance, err := chain.GetBalance(aliceAddr.Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, int64(100), balance)
+
+	history, err := chain.GetAddressHistory(bobAddr.Bytes())
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(history))
+}
@@ -0,0 +1,25 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Anchor is the place a hash was first anchored in a data output.
+type Anchor struct {
+	BlockHash []byte
+	Height    int
+	TxHash    []byte
+}
+
+// GetAnchor returns the block and transaction that first anchored the hash.
+func (c *Chain) GetAnchor(hash []byte) (*Anchor, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	anchor, ok := c.anchors[hex.EncodeToString(hash)]
+	if !ok {
+		return nil, fmt.Errorf("hash %x is not anchored", hash)
+	}
+	return anchor, nil
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAnchorDocumentHash(t *testing.T) {
+	var (
+		node   = NewNode(ServerConfig{Version: "test"})
+		chain  = node.chain
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		doc    = sha256.Sum256([]byte("contract v1"))
+	)
+
+	_, err := NewQueryServer(node).GetAnchor(context.Background(), &proto.AnchorRequest{Hash: doc[:]})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	tx := genesisSpend(t, chain,
+		types.NewDataOutput(doc[:]),
+		&proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()},
+	)
+	block := addBlockWithTx(t, chain, tx)
+
+	resp, err := NewQueryServer(node).GetAnchor(context.Background(), &proto.AnchorRequest{Hash: doc[:]})
+	require.Nil(t, err)
+	assert.Equal(t, types.HashBlock(block), resp.BlockHash)
+	assert.Equal(t, int32(1), resp.Height)
+	assert.Equal(t, types.HashTransaction(tx), resp.TxHash)
+
+	// the data output never becomes spendable
+	key := fmt.Sprintf("%s_%d", hex.EncodeToString(types.HashTransaction(tx)), 0)
+	_, err = chain.utxoStore.Get(key)
+	assert.NotNil(t, err)
+
+	spend := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(tx),
+			PrevOutIndex: 0,
+			PublicKey:    godKey.Public().Bytes(),
+		}},
+	}
+	spend.Inputs[0].Signature = types.SignTransaction(godKey, spend).Bytes()
+	assert.NotNil(t, chain.ValidateTransaction(spend))
+}
+
+func TestValidateDataOutput(t *testing.T) {
+	chain := NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+
+	assert.Nil(t, chain.validateOutput(types.NewDataOutput(make([]byte, types.MaxDataLen)), 1))
+	assert.NotNil(t, chain.validateOutput(types.NewDataOutput(make([]byte, types.MaxDataLen+1)), 1))
+
+	withAmount := types.NewDataOutput([]byte("hello"))
+	withAmount.Amount = 10
+	assert.NotNil(t, chain.validateOutput(withAmount, 1))
+
+	withAddress := types.NewDataOutput([]byte("hello"))
+	withAddress.Address = crypto.GeneratePrivateKey().Public().Address().Bytes()
+	assert.NotNil(t, chain.validateOutput(withAddress, 1))
+
+	withChannel := types.NewDataOutput([]byte("hello"))
+	withChannel.Channel = types.NewChannelOutput(0, crypto.GeneratePrivateKey().Public(), crypto.GeneratePrivateKey().Public(), 10).Channel
+	assert.NotNil(t, chain.validateOutput(withChannel, 1))
+}
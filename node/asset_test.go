@@ -0,0 +1,124 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueAndTransferAsset(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		alice  = crypto.GeneratePrivateKey()
+		bob    = crypto.GeneratePrivateKey()
+	)
+
+	issueTx := genesisSpend(t, chain)
+	issueTx.Payload = &proto.Transaction_Issuance{
+		Issuance: &proto.AssetIssuance{Amount: 500, Name: "points"},
+	}
+	asset, err := types.IssuedAssetID(issueTx)
+	require.Nil(t, err)
+
+	sign := func(tx *proto.Transaction, key *crypto.PrivateKey) {
+		for _, input := range tx.Inputs {
+			input.Signature = nil
+		}
+		sig := types.SignTransaction(key, tx).Bytes()
+		for _, input := range tx.Inputs {
+			input.Signature = sig
+		}
+	}
+
+	// more than issued
+	issueTx.Outputs = []*proto.TxOutput{
+		{Amount: 501, Asset: asset, Address: alice.Public().Address().Bytes()},
+	}
+	sign(issueTx, godKey)
+	require.NotNil(t, chain.ValidateTransaction(issueTx))
+
+	issueTx.Outputs = []*proto.TxOutput{
+		{Amount: 500, Asset: asset, Address: alice.Public().Address().Bytes()},
+		{Amount: 1000, Address: alice.Public().Address().Bytes()},
+	}
+	sign(issueTx, godKey)
+	require.Nil(t, chain.ValidateTransaction(issueTx))
+	addBlockWithTx(t, chain, issueTx)
+
+	transfer := func(outputs ...*proto.TxOutput) *proto.Transaction {
+		tx := &proto.Transaction{
+			Version: 1,
+			Inputs: []*proto.TxInput{
+				{PrevTxHash: types.HashTransaction(issueTx), PrevOutIndex: 0, PublicKey: alice.Public().Bytes()},
+				{PrevTxHash: types.HashTransaction(issueTx), PrevOutIndex: 1, PublicKey: alice.Public().Bytes()},
+			},
+			Outputs: outputs,
+		}
+		sign(tx, alice)
+		return tx
+	}
+
+	// tokens can't pay for native coins
+	tx := transfer(
+		&proto.TxOutput{Amount: 500, Asset: asset, Address: bob.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 1100, Address: alice.Public().Address().Bytes()},
+	)
+	assert.NotNil(t, chain.ValidateTransaction(tx))
+
+	// tokens aren't burned as fees
+	tx = transfer(
+		&proto.TxOutput{Amount: 400, Asset: asset, Address: bob.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()},
+	)
+	assert.NotNil(t, chain.ValidateTransaction(tx))
+
+	// an unknown asset can't be created out of thin air
+	tx = transfer(
+		&proto.TxOutput{Amount: 500, Asset: asset, Address: bob.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 10, Asset: types.AssetID(asset, 0), Address: bob.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()},
+	)
+	assert.NotNil(t, chain.ValidateTransaction(tx))
+
+	tx = transfer(
+		&proto.TxOutput{Amount: 300, Asset: asset, Address: bob.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 200, Asset: asset, Address: alice.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 990, Address: alice.Public().Address().Bytes()},
+	)
+	require.Nil(t, chain.ValidateTransaction(tx))
+	addBlockWithTx(t, chain, tx)
+
+	utxo, err := chain.utxoStore.Get(fmt.Sprintf("%s_%d", hex.EncodeToString(types.HashTransaction(tx)), 0))
+	require.Nil(t, err)
+	assert.Equal(t, asset, utxo.Asset)
+	assert.Equal(t, int64(300), utxo.Amount)
+}
+
+func TestRejectNegativeAndOverflowingAmounts(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		addr   = godKey.Public().Address().Bytes()
+	)
+
+	tx := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 2000, Address: addr},
+		&proto.TxOutput{Amount: -1000, Address: addr},
+	)
+	assert.NotNil(t, chain.ValidateTransaction(tx))
+
+	tx = genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 1 << 62, Address: addr},
+		&proto.TxOutput{Amount: 1 << 62, Address: addr},
+		&proto.TxOutput{Amount: 1 << 62, Address: addr},
+		&proto.TxOutput{Amount: 1 << 62, Address: addr},
+	)
+	assert.NotNil(t, chain.ValidateTransaction(tx))
+}
@@ -4,26 +4,95 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	"github.com/mhg14/ChlockBane/crypto"
 	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
 	"github.com/mhg14/ChlockBane/types"
 )
 
 const godSeed = "6bc49ae98a0f9a9df49427788eb7c73f30299165035c040ab8b4ef56c97b2480"
 
+// maxBlockTimeDrift is how far ahead of the local clock a block timestamp may
+// be.
+const maxBlockTimeDrift = 2 * time.Hour
+
 type UTXO struct {
-	Hash     string
-	OutIndex int
-	Amount   int64
-	Spent    bool
+	Hash       string
+	OutIndex   int
+	Amount     int64
+	Address    []byte
+	LockScript []byte
+	Multisig   *proto.MultisigLock
+	HTLC       *proto.HTLCLock
+	Channel    *proto.ChannelLock
+	// Asset is the ID of the asset the amount is denominated in, empty for
+	// the native coin.
+	Asset []byte
+	// Height is the height of the block that created the output.
+	Height int
+	Spent  bool
+}
+
+// Unspent returns the output as sent to clients.
+func (u *UTXO) Unspent() *proto.UnspentOutput {
+	hash, _ := hex.DecodeString(u.Hash)
+	return &proto.UnspentOutput{
+		TxHash:   hash,
+		OutIndex: uint32(u.OutIndex),
+		Output: &proto.TxOutput{
+			Amount:     u.Amount,
+			Address:    u.Address,
+			LockScript: u.LockScript,
+			Multisig:   u.Multisig,
+			Htlc:       u.HTLC,
+			Asset:      u.Asset,
+			Channel:    u.Channel,
+		},
+		Height: int32(u.Height),
+	}
 }
 
 type Chain struct {
+	lock       sync.RWMutex
+	params     *ChainParams
 	txStore    TXStorer
 	blockStore BlockStorer
 	headers    *HeaderList
 	utxoStore  UTXOStorer
+	// validators is the set allowed to sign the next block. Every change to
+	// it is recorded in validatorHistory.
+	validators        *ValidatorSet
+	validatorsChanged bool
+	validatorHistory  []*validatorSnapshot
+	pendingChanges    map[int][]*proto.ValidatorSetChange
+	// slashed maps the hex public key of every slashed validator to the
+	// bond it forfeited.
+	slashed map[string]int64
+	// preimages maps the hex encoded hash of every claimed HTLC to the
+	// claiming input.
+	preimages map[string]*revealedPreimage
+	// anchors maps every 32 byte hash anchored in a data output to the
+	// first place it was anchored.
+	anchors map[string]*Anchor
+	// names maps every registered name to its record, including expired
+	// ones until they are registered again.
+	names map[string]*NameRecord
+	// txIndex maps the hex encoded hash of every transaction in the chain
+	// to the place it was included.
+	txIndex map[string]*TxLocation
+	// addrIndex is the index of the outputs and transactions of every
+	// address, nil unless enabled with EnableAddressIndex.
+	addrIndex *addressIndex
+	// undoLog holds for every connected block the operations reverting it,
+	// indexed by height.
+	undoLog []*blockUndo
+	// sigCache holds the transactions whose input signatures were already
+	// verified, at mempool admission or in an earlier block.
+	sigCache *SigCache
 }
 
 type HeaderList struct {
@@ -48,6 +117,11 @@ func (list *HeaderList) Height() int {
 	return list.Len() - 1
 }
 
+// RemoveTip removes the last header.
+func (list *HeaderList) RemoveTip() {
+	list.headers = list.headers[:len(list.headers)-1]
+}
+
 func (list *HeaderList) Get(index int) *proto.Header {
 	if index > list.Height() {
 		panic("index too high")
@@ -56,19 +130,39 @@ func (list *HeaderList) Get(index int) *proto.Header {
 }
 
 func NewChain(bs BlockStorer, txStore TXStorer) *Chain {
+	return NewChainWithParams(bs, txStore, DefaultChainParams())
+}
+
+func NewChainWithParams(bs BlockStorer, txStore TXStorer, params *ChainParams) *Chain {
 	chain := &Chain{
-		blockStore: bs,
-		txStore:    txStore,
-		utxoStore:  NewMemoryUTXOStore(),
-		headers:    NewHeaderList(),
+		params:         params,
+		blockStore:     bs,
+		txStore:        txStore,
+		utxoStore:      NewMemoryUTXOStore(),
+		headers:        NewHeaderList(),
+		validators:     NewValidatorSet(params.GenesisValidators...),
+		pendingChanges: make(map[int][]*proto.ValidatorSetChange),
+		slashed:        make(map[string]int64),
+		preimages:      make(map[string]*revealedPreimage),
+		anchors:        make(map[string]*Anchor),
+		names:          make(map[string]*NameRecord),
+		txIndex:        make(map[string]*TxLocation),
+		sigCache:       NewSigCache(sigCacheSize),
 	}
+	chain.validatorHistory = []*validatorSnapshot{{
+		height: 0,
+		set:    chain.validators.Copy(),
+	}}
 
 	chain.addBlock(createGenesisBlock())
 	return chain
 }
 
 func (c *Chain) AddBlock(b *proto.Block) error {
-	if err := c.ValidateBlock(b); err != nil {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.validateBlock(b); err != nil {
 		return err
 	}
 
@@ -81,27 +175,72 @@ func (c *Chain) GetBlockByHash(hash []byte) (*proto.Block, error) {
 	return c.blockStore.Get(hashHex)
 }
 
+// HasBlock reports whether a block with the given hash is part of the chain.
+func (c *Chain) HasBlock(hash []byte) bool {
+	_, err := c.GetBlockByHash(hash)
+	return err == nil
+}
+
+// Validators returns a copy of the current validator set.
+func (c *Chain) Validators() *ValidatorSet {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.validators.Copy()
+}
+
+// SlashedBond returns the bond forfeited by the given validator and whether
+// it has been slashed at all.
+func (c *Chain) SlashedBond(pubKey []byte) (int64, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	bond, ok := c.slashed[hex.EncodeToString(pubKey)]
+	return bond, ok
+}
+
 func (c *Chain) addBlock(b *proto.Block) error {
+	undo := &blockUndo{}
+	c.undoLog = append(c.undoLog, undo)
 	c.headers.Add(b.Header)
+	undo.add(c.saveValidatorState())
+	blockHash := types.HashBlock(b)
 
-	for _, tx := range b.Transactions {
+	for i, tx := range b.Transactions {
 		if err := c.txStore.Put(tx); err != nil {
 			return err
 		}
+		c.indexTransaction(undo, tx, blockHash, int(b.Header.Height), i)
 
 		hash := hex.EncodeToString(types.HashTransaction(tx))
 
 		for it, output := range tx.Outputs {
+			// data outputs can't be spent, so they never enter the UTXO set
+			if types.IsDataOutput(output) {
+				c.indexAnchor(undo, output.Data, b, tx)
+				continue
+			}
 			utxo := &UTXO{
-				Hash:     hash,
-				Amount:   output.Amount,
-				OutIndex: it,
-				Spent:    false,
+				Hash:       hash,
+				Amount:     output.Amount,
+				OutIndex:   it,
+				Address:    output.Address,
+				LockScript: output.LockScript,
+				Multisig:   output.Multisig,
+				HTLC:       output.Htlc,
+				Channel:    output.Channel,
+				Asset:      output.Asset,
+				Height:     int(b.Header.Height),
+				Spent:      false,
 			}
 
 			if err := c.utxoStore.Put(utxo); err != nil {
 				return err
 			}
+			key := fmt.Sprintf("%s_%d", hash, it)
+			undo.add(func() error {
+				return c.utxoStore.Delete(key)
+			})
 		}
 
 		for _, input := range tx.Inputs {
@@ -114,34 +253,170 @@ func (c *Chain) addBlock(b *proto.Block) error {
 			if err := c.utxoStore.Put(utxo); err != nil {
 				return err
 			}
+			undo.add(func() error {
+				utxo.Spent = false
+				return c.utxoStore.Put(utxo)
+			})
+			if utxo.HTLC != nil && len(input.Preimage) > 0 {
+				c.recordPreimage(undo, utxo.HTLC.Hash, input.Preimage, types.HashTransaction(tx))
+			}
+		}
+
+		if c.addrIndex != nil {
+			if err := c.indexAddresses(undo, tx, int(b.Header.Height)); err != nil {
+				return err
+			}
 		}
 
+		if ev := tx.GetEvidence(); ev != nil {
+			c.slash(ev.First.PublicKey)
+		}
+		if gov := tx.GetGovernance(); gov != nil {
+			c.scheduleValidatorSetChange(gov.Change)
+		}
+		if op := tx.GetName(); op != nil {
+			c.applyNameOperation(undo, op, int(b.Header.Height))
+		}
 	}
 
+	c.advanceValidatorSet(int(b.Header.Height))
+
 	return c.blockStore.Put(b)
 }
 
+func (c *Chain) indexAnchor(undo *blockUndo, data []byte, b *proto.Block, tx *proto.Transaction) {
+	if len(data) != types.AnchorLen {
+		return
+	}
+	key := hex.EncodeToString(data)
+	if _, ok := c.anchors[key]; ok {
+		return
+	}
+	c.anchors[key] = &Anchor{
+		BlockHash: types.HashBlock(b),
+		Height:    int(b.Header.Height),
+		TxHash:    types.HashTransaction(tx),
+	}
+	undo.add(func() error {
+		delete(c.anchors, key)
+		return nil
+	})
+}
+
+// slash removes the validator from the set and burns its bond.
+func (c *Chain) slash(pubKey []byte) {
+	v, ok := c.validators.Get(pubKey)
+	if !ok {
+		return
+	}
+	c.validators.Remove(pubKey)
+	c.validatorsChanged = true
+	c.slashed[hex.EncodeToString(pubKey)] = v.Bond
+}
+
 func (c *Chain) GetBlockByHeight(height int) (*proto.Block, error) {
-	if c.Height() < height {
-		return nil, fmt.Errorf("given height %d too high, current chain height is %d", height, c.Height())
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.getBlockByHeight(height)
+}
+
+func (c *Chain) getBlockByHeight(height int) (*proto.Block, error) {
+	if c.headers.Height() < height {
+		return nil, fmt.Errorf("given height %d too high, current chain height is %d", height, c.headers.Height())
 	}
 	header := c.headers.Get(height)
 	hash := types.HashHeader(header)
 	return c.GetBlockByHash(hash)
 }
 
+// GetHeaders returns the headers from height from to height to, both
+// included.
+func (c *Chain) GetHeaders(from, to int) ([]*proto.Header, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("invalid header range %d to %d", from, to)
+	}
+	if to > c.headers.Height() {
+		return nil, fmt.Errorf("given height %d too high, current chain height is %d", to, c.headers.Height())
+	}
+	return append([]*proto.Header{}, c.headers.headers[from:to+1]...), nil
+}
+
+// Tip returns the height and the hash of the last block.
+func (c *Chain) Tip() (int, []byte) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	height := c.headers.Height()
+	return height, types.HashHeader(c.headers.Get(height))
+}
+
+// GetUTXO returns the unspent output at the given index of a transaction.
+func (c *Chain) GetUTXO(txHash []byte, index int) (*UTXO, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	utxo, err := c.utxoStore.Get(fmt.Sprintf("%s_%d", hex.EncodeToString(txHash), index))
+	if err != nil {
+		return nil, err
+	}
+	if utxo.Spent {
+		return nil, fmt.Errorf("output %d of transaction %x is spent", index, txHash)
+	}
+	return utxo, nil
+}
+
 func (c *Chain) Height() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	return c.headers.Height()
 }
 
 func (c *Chain) ValidateBlock(b *proto.Block) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.validateBlock(b)
+}
+
+func (c *Chain) validateBlock(b *proto.Block) error {
 	// Validate the signature of the block
 	if !types.VerifyBlock(b) {
 		return fmt.Errorf("invalid block signature")
 	}
 
+	validators := c.validatorsAt(int(b.Header.Height))
+	if validators.Len() > 0 && !validators.Has(b.PublicKey) {
+		return fmt.Errorf("block signer %x is not a validator", b.PublicKey)
+	}
+
+	return c.validateBlockContents(b)
+}
+
+// ValidateUnsignedBlock checks everything about the block except its signature
+// and signer. Validators run it before signing a block, since a signed block
+// can't be replaced by another one at the same height.
+func (c *Chain) ValidateUnsignedBlock(b *proto.Block) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if b.Header == nil {
+		return fmt.Errorf("block has no header")
+	}
+	return c.validateBlockContents(b)
+}
+
+func (c *Chain) validateBlockContents(b *proto.Block) error {
+	if int(b.Header.Height) != c.headers.Height()+1 {
+		return fmt.Errorf("invalid block height %d, expected %d", b.Header.Height, c.headers.Height()+1)
+	}
+
 	// Validate if the prevHash is the actual hash of the current block
-	currentBlock, err := c.GetBlockByHeight(c.Height())
+	currentBlock, err := c.getBlockByHeight(c.headers.Height())
 	if err != nil {
 		return err
 	}
@@ -152,47 +427,341 @@ func (c *Chain) ValidateBlock(b *proto.Block) error {
 		return fmt.Errorf("invalid previous block hash")
 	}
 
+	height := int(b.Header.Height)
+	if c.params.IsActive(ForkEnforceVersions, height) && !types.IsKnownBlockVersion(b.Header.Version) {
+		return fmt.Errorf("unknown block version %d", b.Header.Version)
+	}
+
+	// the median keeps a single validator from moving the chain's clock
+	// back, the drift from moving it far ahead
+	if median := c.medianTimestamp(); b.Header.Timestamp <= median {
+		return fmt.Errorf("block timestamp %d is not after the median time past %d", b.Header.Timestamp, median)
+	}
+	if limit := time.Now().Add(maxBlockTimeDrift).UnixNano(); b.Header.Timestamp > limit {
+		return fmt.Errorf("block timestamp %d is too far in the future", b.Header.Timestamp)
+	}
+
+	// Verify all signatures up front in parallel, the checks of the single
+	// transactions below then find them in the cache.
+	if err := verifySignatures(b.Transactions, c.utxoStore, c.sigCache, height); err != nil {
+		return err
+	}
+
+	var (
+		spent   = make(map[string]bool)
+		names   = make(map[string]bool)
+		slashed = make(map[string]bool)
+		changes []*proto.ValidatorSetChange
+	)
 	for _, tx := range b.Transactions {
-		if err := c.ValidateTransaction(tx); err != nil {
+		if err := c.validateTransaction(tx, height); err != nil {
 			return err
 		}
+		if ev := tx.GetEvidence(); ev != nil {
+			slashed[hex.EncodeToString(ev.First.PublicKey)] = true
+			if len(slashed) >= c.validators.Len() {
+				return fmt.Errorf("block slashes every validator")
+			}
+		}
+		// proposals in the same block are checked against each other, not
+		// only against the ones already scheduled
+		if gov := tx.GetGovernance(); gov != nil {
+			changes = append(changes, gov.Change)
+			if err := c.checkScheduledChanges(changes); err != nil {
+				return err
+			}
+		}
+		if op := tx.GetName(); op != nil {
+			if names[op.Name] {
+				return fmt.Errorf("name %q is changed twice in the block", op.Name)
+			}
+			names[op.Name] = true
+		}
+		for _, input := range tx.Inputs {
+			key := fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+			if spent[key] {
+				return fmt.Errorf("output %s is spent twice in the block", key)
+			}
+			spent[key] = true
+		}
 	}
 	return nil
 }
 
 func (c *Chain) ValidateTransaction(tx *proto.Transaction) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.validateTransaction(tx, c.headers.Height()+1)
+}
+
+// validateTransaction checks the transaction against the current state, under
+// the rules active at the height of the block it is going to be included in.
+func (c *Chain) validateTransaction(tx *proto.Transaction, height int) error {
+	if c.params.IsActive(ForkEnforceVersions, height) && !types.IsKnownTransactionVersion(tx.Version) {
+		return fmt.Errorf("unknown transaction version %d", tx.Version)
+	}
+
+	if ev := tx.GetEvidence(); ev != nil {
+		if err := c.validateEvidence(ev); err != nil {
+			return err
+		}
+	}
+	if gov := tx.GetGovernance(); gov != nil {
+		if err := c.validateGovernance(gov); err != nil {
+			return err
+		}
+	}
+	if op := tx.GetName(); op != nil {
+		if err := c.validateNameOperation(tx, op, height); err != nil {
+			return err
+		}
+	}
+
+	if c.params.IsActive(ForkTimelocks, height) {
+		if err := c.checkTimelocks(tx, height); err != nil {
+			return err
+		}
+	} else if hasTimelocks(tx) {
+		return fmt.Errorf("lock times are not active at height %d", height)
+	}
+
 	// check the signature
-	if !types.VerifyTransaction(tx) {
-		return fmt.Errorf("invalid tx signature")
+	if err := checkSignatures(tx, c.sigCache); err != nil {
+		return fmt.Errorf("invalid tx signature: %w", err)
 	}
 
 	// verify if all the inputs are unspent
 	var (
-		nInputs = len(tx.Inputs)
 		hash    = types.HashTransaction(tx)
+		sigHash = types.SigHash(tx)
+		inputs  = make(amounts)
+		outputs = make(amounts)
 	)
-	sumInputs := 0
 
-	for i := 0; i < nInputs; i++ {
-		prevHash := hex.EncodeToString(tx.Inputs[0].PrevTxHash)
-		key := fmt.Sprintf("%s_%d", prevHash, i)
+	for i, input := range tx.Inputs {
+		prevHash := hex.EncodeToString(input.PrevTxHash)
+		key := fmt.Sprintf("%s_%d", prevHash, input.PrevOutIndex)
 		utxo, err := c.utxoStore.Get(key)
 		if err != nil {
 			return err
 		}
-		sumInputs += int(utxo.Amount)
+		if err := inputs.add(utxo.Asset, utxo.Amount); err != nil {
+			return err
+		}
 		if utxo.Spent {
-			return fmt.Errorf("input %d of tx %s is already spent", i, hash)
+			return fmt.Errorf("input %d of tx %x is already spent", i, hash)
+		}
+		if err := c.validateUnlock(input, utxo, sigHash, inputCacheKey(hash, i), height); err != nil {
+			return fmt.Errorf("input %d of tx %x: %w", i, hash, err)
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		if err := c.validateOutput(output, height); err != nil {
+			return fmt.Errorf("output %d of tx %x: %w", i, hash, err)
+		}
+		if err := outputs.add(output.Asset, output.Amount); err != nil {
+			return err
+		}
+	}
+
+	if issuance := tx.GetIssuance(); issuance != nil {
+		if !c.params.IsActive(ForkAssets, height) {
+			return fmt.Errorf("asset issuance is not active at height %d", height)
+		}
+		if err := types.ValidateIssuance(issuance); err != nil {
+			return err
+		}
+		asset, err := types.IssuedAssetID(tx)
+		if err != nil {
+			return err
+		}
+		if err := inputs.add(asset, issuance.Amount); err != nil {
+			return err
+		}
+	}
+
+	return checkConservation(inputs, outputs)
+}
+
+// amounts sums amounts per asset, keyed by the hex encoded asset ID.
+type amounts map[string]int64
+
+func (a amounts) add(asset []byte, amount int64) error {
+	key := hex.EncodeToString(asset)
+	if amount < 0 {
+		return fmt.Errorf("negative amount %d", amount)
+	}
+	if a[key] > math.MaxInt64-amount {
+		return fmt.Errorf("amount overflow")
+	}
+	a[key] += amount
+	return nil
+}
+
+// checkConservation checks that the outputs don't create value. The native
+// coin may be spent on fees, so its inputs only have to cover the outputs.
+// Every other asset must be fully accounted for by the outputs.
+func checkConservation(inputs, outputs amounts) error {
+	if inputs[""] < outputs[""] {
+		return fmt.Errorf("insufficient balance, got (%d) spending (%d)", inputs[""], outputs[""])
+	}
+	for asset, amount := range outputs {
+		if asset != "" && inputs[asset] != amount {
+			return fmt.Errorf("asset %s not conserved, got (%d) spending (%d)", asset, inputs[asset], amount)
+		}
+	}
+	for asset, amount := range inputs {
+		if asset != "" && outputs[asset] != amount {
+			return fmt.Errorf("asset %s not conserved, got (%d) spending (%d)", asset, amount, outputs[asset])
+		}
+	}
+	return nil
+}
+
+func (c *Chain) validateOutput(output *proto.TxOutput, height int) error {
+	if types.IsDataOutput(output) {
+		if !c.params.IsActive(ForkDataOutputs, height) {
+			return fmt.Errorf("data outputs are not active at height %d", height)
+		}
+		return types.ValidateDataOutput(output)
+	}
+
+	if len(output.Asset) > 0 {
+		if !c.params.IsActive(ForkAssets, height) {
+			return fmt.Errorf("asset outputs are not active at height %d", height)
+		}
+		if len(output.Asset) != types.AssetIDLen {
+			return fmt.Errorf("invalid asset ID length %d", len(output.Asset))
 		}
 	}
 
-	sumOutputs := 0
-	for _, output := range tx.Outputs {
-		sumOutputs += int(output.Amount)
+	if len(output.LockScript) > 0 && !c.params.IsActive(ForkScripts, height) {
+		return fmt.Errorf("lock scripts are not active at height %d", height)
 	}
 
-	if sumInputs < sumOutputs {
-		return fmt.Errorf("insufficient balance, got (%d) spending (%d)", sumInputs, sumOutputs)
+	locks := 0
+	for _, set := range []bool{len(output.LockScript) > 0, output.Multisig != nil, output.Htlc != nil, output.Channel != nil} {
+		if set {
+			locks++
+		}
+	}
+	if locks > 1 {
+		return fmt.Errorf("output has more than one lock")
+	}
+
+	if output.Channel != nil {
+		if !c.params.IsActive(ForkChannels, height) {
+			return fmt.Errorf("payment channels are not active at height %d", height)
+		}
+		return types.ValidateChannelLock(output.Channel)
+	}
+
+	if output.Htlc != nil {
+		if !c.params.IsActive(ForkHTLC, height) {
+			return fmt.Errorf("HTLC outputs are not active at height %d", height)
+		}
+		return types.ValidateHTLCLock(output.Htlc)
+	}
+
+	if output.Multisig == nil {
+		return nil
+	}
+	if !c.params.IsActive(ForkMultisig, height) {
+		return fmt.Errorf("multisig outputs are not active at height %d", height)
+	}
+	if err := types.ValidateMultisigLock(output.Multisig); err != nil {
+		return err
+	}
+	if !bytes.Equal(types.MultisigLockAddress(output.Multisig).Bytes(), output.Address) {
+		return fmt.Errorf("output address is not the address of its multisig lock")
+	}
+	return nil
+}
+
+// validateUnlock checks that the input is allowed to spend the output. Outputs
+// with a lock script need an unlock script satisfying it, multisig outputs
+// enough signatures of their keys, HTLC outputs the recipient with the preimage
+// or the sender after the timeout, channel outputs both parties or the payer
+// after the timeout, and other outputs need the input to be
+// signed by the owner of the output address. Signatures of the input itself
+// are checked by checkSignatures.
+func (c *Chain) validateUnlock(input *proto.TxInput, utxo *UTXO, sigHash, cacheKey []byte, height int) error {
+	if utxo.HTLC != nil {
+		if len(input.UnlockScript) > 0 || len(input.Signatures) > 0 {
+			return fmt.Errorf("HTLC output must be spent with a single signature")
+		}
+		spender, err := crypto.ParsePublicKey(input.PublicKey)
+		if err != nil {
+			return err
+		}
+		return types.VerifyHTLCSpend(utxo.HTLC, input, spender.Address(), height)
+	}
+	if len(input.Preimage) > 0 {
+		return fmt.Errorf("preimage given for an output without HTLC lock")
+	}
+
+	if utxo.Channel != nil {
+		return checkLockSignatures(input, utxo, sigHash, cacheKey, height, c.sigCache)
+	}
+
+	if utxo.Multisig != nil {
+		if len(input.UnlockScript) > 0 || len(input.Signature) > 0 {
+			return fmt.Errorf("multisig output must be spent with multisig signatures only")
+		}
+		return checkLockSignatures(input, utxo, sigHash, cacheKey, height, c.sigCache)
+	}
+	if len(input.Signatures) > 0 {
+		return fmt.Errorf("multisig signatures given for an output without multisig lock")
+	}
+
+	if len(utxo.LockScript) == 0 {
+		if len(input.UnlockScript) > 0 {
+			return fmt.Errorf("unlock script given for an output without lock script")
+		}
+		if !c.params.IsActive(ForkEnforceOwnership, height) {
+			return nil
+		}
+		pubKey, err := crypto.ParsePublicKey(input.PublicKey)
+		if err != nil {
+			return err
+		}
+		owner := pubKey.Address()
+		if !bytes.Equal(owner.Bytes(), utxo.Address) {
+			return fmt.Errorf("spender %s does not own output address %x", owner, utxo.Address)
+		}
+		return nil
+	}
+
+	if !c.params.IsActive(ForkScripts, height) {
+		return fmt.Errorf("lock scripts are not active at height %d", height)
+	}
+	ctx := &script.Context{
+		SigHash: sigHash,
+		Height:  int64(height),
+	}
+	return script.Execute(input.UnlockScript, utxo.LockScript, ctx)
+}
+
+func (c *Chain) validateEvidence(ev *proto.DoubleSignEvidence) error {
+	if err := types.VerifyDoubleSignEvidence(ev); err != nil {
+		return err
+	}
+
+	pubKey := ev.First.PublicKey
+	if _, ok := c.slashed[hex.EncodeToString(pubKey)]; ok {
+		return fmt.Errorf("validator %x is already slashed", pubKey)
+	}
+	if !c.validatorsAt(int(ev.First.Header.Height)).Has(pubKey) {
+		return fmt.Errorf("evidence signer %x was not a validator at height %d", pubKey, ev.First.Header.Height)
+	}
+	if !c.validators.Has(pubKey) {
+		return fmt.Errorf("evidence signer %x is no longer a validator", pubKey)
+	}
+	// an empty set lets any key sign blocks
+	if c.validators.Len() == 1 {
+		return fmt.Errorf("slashing %x would remove every validator", pubKey)
 	}
 	return nil
 }
@@ -1,10 +1,12 @@
 package node
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"github.com/mhg14/ChlockBane/crypto"
 	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
 	"github.com/mhg14/ChlockBane/types"
 	"github.com/mhg14/ChlockBane/util"
 	"github.com/stretchr/testify/assert"
@@ -50,6 +52,7 @@ func randomBlock(t *testing.T, chain *Chain) *proto.Block {
 	prevBlock, err := chain.GetBlockByHeight(chain.Height())
 	require.Nil(t, err)
 	b.Header.PrevHash = types.HashBlock(prevBlock)
+	b.Header.Height = int32(chain.Height() + 1)
 
 	privKey := crypto.GeneratePrivateKey()
 	types.SignBlock(privKey, b)
@@ -64,7 +67,7 @@ func TestAddBlockWithInsufficientFunds(t *testing.T) {
 		recipient = crypto.GeneratePrivateKey().Public().Address().Bytes()
 	)
 
-	prevTx, err := chain.txStore.Get("8f26b010c9db9857962c5faaaf1aa629506cc1646a129ce92f525c1776bb8b78")
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
 	assert.Nil(t, err)
 
 	inputs := []*proto.TxInput{
@@ -98,7 +101,7 @@ func TestAddBlockWithTx(t *testing.T) {
 		recipient = crypto.GeneratePrivateKey().Public().Address().Bytes()
 	)
 
-	prevTx, err := chain.txStore.Get("8f26b010c9db9857962c5faaaf1aa629506cc1646a129ce92f525c1776bb8b78")
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
 	assert.Nil(t, err)
 
 	inputs := []*proto.TxInput{
@@ -125,3 +128,368 @@ func TestAddBlockWithTx(t *testing.T) {
 	types.SignBlock(privKey, block)
 	require.Nil(t, chain.AddBlock(block))
 }
+
+func TestAddBlockInvalidHeight(t *testing.T) {
+	chain := NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+	block := randomBlock(t, chain)
+	block.Header.Height = 5
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.NotNil(t, chain.AddBlock(block))
+}
+
+func TestAddBlockFromNonValidator(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		params    = &ChainParams{
+			GenesisValidators: []*Validator{{PublicKey: validator.Public(), Bond: 100}},
+		}
+		chain = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+	)
+
+	// randomBlock signs with a random key
+	require.NotNil(t, chain.AddBlock(randomBlock(t, chain)))
+
+	block := randomBlock(t, chain)
+	types.SignBlock(validator, block)
+	require.Nil(t, chain.AddBlock(block))
+}
+
+func TestSlashDoubleSigner(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		other     = crypto.GeneratePrivateKey()
+		params    = &ChainParams{
+			GenesisValidators: []*Validator{
+				{PublicKey: validator.Public(), Bond: 100},
+				{PublicKey: other.Public(), Bond: 100},
+			},
+		}
+		chain = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+	)
+
+	first := randomBlock(t, chain)
+	types.SignBlock(validator, first)
+	second := randomBlock(t, chain)
+	types.SignBlock(validator, second)
+	require.Nil(t, chain.AddBlock(first))
+
+	detector := NewDoubleSignDetector(chain)
+	assert.Nil(t, detector.Observe(first))
+	assert.Nil(t, detector.Observe(first))
+	ev := detector.Observe(second)
+	require.NotNil(t, ev)
+
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, types.NewDoubleSignTransaction(ev.First, ev.Second))
+	types.SignBlock(other, block)
+	require.Nil(t, chain.AddBlock(block))
+
+	bond, ok := chain.SlashedBond(validator.Public().Bytes())
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), bond)
+	assert.False(t, chain.Validators().Has(validator.Public().Bytes()))
+
+	// the slashed validator can no longer produce blocks
+	block = randomBlock(t, chain)
+	types.SignBlock(validator, block)
+	require.NotNil(t, chain.AddBlock(block))
+
+	// the same evidence can not be used twice
+	block = randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, types.NewDoubleSignTransaction(ev.First, ev.Second))
+	types.SignBlock(other, block)
+	require.NotNil(t, chain.AddBlock(block))
+}
+
+func TestForkEnforceVersions(t *testing.T) {
+	params := DefaultChainParams()
+	params.Forks[ForkEnforceVersions] = 2
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	// before activation unknown versions are accepted
+	block := randomBlock(t, chain)
+	block.Header.Version = types.BlockVersion + 1
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.Nil(t, chain.AddBlock(block))
+
+	block = randomBlock(t, chain)
+	block.Header.Version = types.BlockVersion + 1
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.NotNil(t, chain.AddBlock(block))
+
+	block.Header.Version = types.BlockVersion
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.Nil(t, chain.AddBlock(block))
+
+	assert.False(t, params.IsActive(ForkEnforceVersions, 1))
+	assert.True(t, params.IsActive(ForkEnforceVersions, 2))
+	assert.False(t, params.IsActive("unscheduled", 100))
+}
+
+func TestSpendPayToAddressOutput(t *testing.T) {
+	var (
+		chain     = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		privKey   = crypto.NewPrivateKeyFromSeedString(godSeed)
+		recipient = crypto.GeneratePrivateKey()
+	)
+
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
+	require.Nil(t, err)
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(prevTx),
+			PrevOutIndex: 0,
+			PublicKey:    privKey.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{{
+			Amount:     1000,
+			LockScript: script.PayToAddress(recipient.Public().Address()),
+		}},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(privKey, tx).Bytes()
+
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, tx)
+	types.SignBlock(privKey, block)
+	require.Nil(t, chain.AddBlock(block))
+
+	spend := func(signer *crypto.PrivateKey) *proto.Block {
+		spendTx := &proto.Transaction{
+			Version: 1,
+			Inputs: []*proto.TxInput{{
+				PrevTxHash:   types.HashTransaction(tx),
+				PrevOutIndex: 0,
+			}},
+			Outputs: []*proto.TxOutput{{
+				Amount:  1000,
+				Address: signer.Public().Address().Bytes(),
+			}},
+		}
+		sig := types.SignTransaction(signer, spendTx)
+		spendTx.Inputs[0].UnlockScript = script.SignatureScript(sig, signer.Public())
+
+		block := randomBlock(t, chain)
+		block.Transactions = append(block.Transactions, spendTx)
+		types.SignBlock(signer, block)
+		return block
+	}
+
+	require.NotNil(t, chain.AddBlock(spend(crypto.GeneratePrivateKey())))
+	require.Nil(t, chain.AddBlock(spend(recipient)))
+}
+
+func TestSpendSomeoneElsesOutput(t *testing.T) {
+	var (
+		chain   = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey  = crypto.NewPrivateKeyFromSeedString(godSeed)
+		thief   = crypto.GeneratePrivateKey()
+		genesis = chain.Height()
+	)
+
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
+	require.Nil(t, err)
+
+	stealTx := func(pubKey []byte, signer *crypto.PrivateKey) *proto.Transaction {
+		tx := &proto.Transaction{
+			Version: 1,
+			Inputs: []*proto.TxInput{{
+				PrevTxHash:   types.HashTransaction(prevTx),
+				PrevOutIndex: 0,
+				PublicKey:    pubKey,
+			}},
+			Outputs: []*proto.TxOutput{{
+				Amount:  1000,
+				Address: thief.Public().Address().Bytes(),
+			}},
+		}
+		tx.Inputs[0].Signature = types.SignTransaction(signer, tx).Bytes()
+		return tx
+	}
+
+	// validly signed by the thief, but the output belongs to god
+	tx := stealTx(thief.Public().Bytes(), thief)
+	require.NotNil(t, chain.ValidateTransaction(tx))
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, tx)
+	types.SignBlock(thief, block)
+	require.NotNil(t, chain.AddBlock(block))
+
+	// claims god's public key but can't produce god's signature
+	tx = stealTx(godKey.Public().Bytes(), thief)
+	require.NotNil(t, chain.ValidateTransaction(tx))
+
+	require.Equal(t, genesis, chain.Height())
+
+	// god can spend it
+	tx = stealTx(godKey.Public().Bytes(), godKey)
+	require.Nil(t, chain.ValidateTransaction(tx))
+}
+
+func TestOwnershipPerInput(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		alice  = crypto.GeneratePrivateKey()
+		bob    = crypto.GeneratePrivateKey()
+	)
+
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
+	require.Nil(t, err)
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(prevTx),
+			PrevOutIndex: 0,
+			PublicKey:    godKey.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{
+			{Amount: 500, Address: alice.Public().Address().Bytes()},
+			{Amount: 500, Address: bob.Public().Address().Bytes()},
+		},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(godKey, tx).Bytes()
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, tx)
+	types.SignBlock(godKey, block)
+	require.Nil(t, chain.AddBlock(block))
+
+	// alice tries to spend both her own and bob's output in one transaction
+	theft := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{
+			{PrevTxHash: types.HashTransaction(tx), PrevOutIndex: 0, PublicKey: alice.Public().Bytes()},
+			{PrevTxHash: types.HashTransaction(tx), PrevOutIndex: 1, PublicKey: alice.Public().Bytes()},
+		},
+		Outputs: []*proto.TxOutput{
+			{Amount: 1000, Address: alice.Public().Address().Bytes()},
+		},
+	}
+	sig := types.SignTransaction(alice, theft).Bytes()
+	theft.Inputs[0].Signature = sig
+	theft.Inputs[1].Signature = sig
+	require.NotNil(t, chain.ValidateTransaction(theft))
+
+	// with bob signing his own input it is fine
+	theft.Inputs[1].PublicKey = bob.Public().Bytes()
+	sigHash := types.SigHash(theft)
+	theft.Inputs[0].Signature = alice.Sign(sigHash).Bytes()
+	theft.Inputs[1].Signature = bob.Sign(sigHash).Bytes()
+	require.Nil(t, chain.ValidateTransaction(theft))
+}
+
+// genesisSpend returns a transaction signed by the god key that spends the
+// genesis output into the given outputs.
+func genesisSpend(t *testing.T, chain *Chain, outputs ...*proto.TxOutput) *proto.Transaction {
+	godKey := crypto.NewPrivateKeyFromSeedString(godSeed)
+	genesis, err := chain.GetBlockByHeight(0)
+	require.Nil(t, err)
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(genesis.Transactions[0]),
+			PrevOutIndex: 0,
+			PublicKey:    godKey.Public().Bytes(),
+		}},
+		Outputs: outputs,
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(godKey, tx).Bytes()
+	return tx
+}
+
+// addBlockWithTx adds a block containing the given transactions to the chain.
+func addBlockWithTx(t *testing.T, chain *Chain, txx ...*proto.Transaction) *proto.Block {
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, txx...)
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.Nil(t, chain.AddBlock(block))
+	return block
+}
+
+// genesisTxHash returns the hex hash of the transaction funding the god key.
+func genesisTxHash(t *testing.T, chain *Chain) string {
+	genesis, err := chain.GetBlockByHeight(0)
+	require.Nil(t, err)
+	return hex.EncodeToString(types.HashTransaction(genesis.Transactions[0]))
+}
+
+func TestDoubleSignDetectorIgnoresUnknownHeaders(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		stranger  = crypto.GeneratePrivateKey()
+		params    = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	var (
+		chain    = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+		detector = NewDoubleSignDetector(chain)
+	)
+
+	// headers of keys outside the validator set are not kept
+	first := randomBlock(t, chain)
+	types.SignBlock(stranger, first)
+	second := randomBlock(t, chain)
+	types.SignBlock(stranger, second)
+	assert.Nil(t, detector.Observe(first))
+	assert.Nil(t, detector.Observe(second))
+
+	// neither are headers far above the tip
+	first = randomBlock(t, chain)
+	first.Header.Height = 1000
+	types.SignBlock(validator, first)
+	second = randomBlock(t, chain)
+	second.Header.Height = 1000
+	types.SignBlock(validator, second)
+	assert.Nil(t, detector.Observe(first))
+	assert.Nil(t, detector.Observe(second))
+	assert.Empty(t, detector.headers)
+}
+
+func TestSlashLastValidator(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		params    = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	first := randomBlock(t, chain)
+	types.SignBlock(validator, first)
+	second := randomBlock(t, chain)
+	types.SignBlock(validator, second)
+	require.Nil(t, chain.AddBlock(first))
+
+	// slashing the only validator would let any key sign blocks
+	tx := types.NewDoubleSignTransaction(types.SignedHeaderFromBlock(first), types.SignedHeaderFromBlock(second))
+	require.NotNil(t, chain.ValidateTransaction(tx))
+	assert.True(t, chain.Validators().Has(validator.Public().Bytes()))
+}
+
+func TestSlashEveryValidatorInOneBlock(t *testing.T) {
+	var (
+		validators = []*crypto.PrivateKey{crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()}
+		params     = DefaultChainParams()
+	)
+	for _, v := range validators {
+		params.GenesisValidators = append(params.GenesisValidators, &Validator{PublicKey: v.Public(), Bond: 100})
+	}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	block := randomBlock(t, chain)
+	for _, v := range validators {
+		first := randomBlock(t, chain)
+		types.SignBlock(v, first)
+		second := randomBlock(t, chain)
+		types.SignBlock(v, second)
+		tx := types.NewDoubleSignTransaction(types.SignedHeaderFromBlock(first), types.SignedHeaderFromBlock(second))
+		require.Nil(t, chain.ValidateTransaction(tx))
+		block.Transactions = append(block.Transactions, tx)
+	}
+	types.SignBlock(validators[0], block)
+	err := chain.AddBlock(block)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "every validator")
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/channel"
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fundChannel(t *testing.T, chain *Chain, payer, payee *crypto.PrivateKey, timeout int32) *channel.Channel {
+	ch := &channel.Channel{
+		Payer:    payer.Public(),
+		Payee:    payee.Public(),
+		Capacity: 1000,
+		Fee:      10,
+		Timeout:  timeout,
+	}
+	fundTx := genesisSpend(t, chain, ch.FundingOutput())
+	addBlockWithTx(t, chain, fundTx)
+	ch.FundingTxHash = types.HashTransaction(fundTx)
+	return ch
+}
+
+func TestChannelClose(t *testing.T) {
+	var (
+		chain    = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = fundChannel(t, chain, payerKey, payeeKey, 100)
+	)
+
+	payer, err := channel.NewPayer(ch, payerKey)
+	require.Nil(t, err)
+	payee, err := channel.NewPayee(ch, payeeKey)
+	require.Nil(t, err)
+
+	for _, amount := range []int64{100, 200, 50} {
+		update, err := payer.Pay(amount)
+		require.Nil(t, err)
+		require.Nil(t, payee.Receive(update))
+	}
+	assert.Equal(t, int64(350), payee.Balance())
+
+	closeTx, err := payee.CloseTransaction()
+	require.Nil(t, err)
+
+	// a close needs both signatures
+	oneSig := types.SignTransaction(payeeKey, closeTx)
+	tampered := &proto.Transaction{
+		Version: closeTx.Version,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   closeTx.Inputs[0].PrevTxHash,
+			PrevOutIndex: closeTx.Inputs[0].PrevOutIndex,
+			Signatures:   [][]byte{oneSig.Bytes(), oneSig.Bytes()},
+		}},
+		Outputs: closeTx.Outputs,
+	}
+	require.NotNil(t, chain.ValidateTransaction(tampered))
+
+	addBlockWithTx(t, chain, closeTx)
+
+	closeHash := hex.EncodeToString(types.HashTransaction(closeTx))
+	utxo, err := chain.utxoStore.Get(fmt.Sprintf("%s_%d", closeHash, 0))
+	require.Nil(t, err)
+	assert.Equal(t, int64(350), utxo.Amount)
+	assert.Equal(t, payeeKey.Public().Address().Bytes(), utxo.Address)
+	utxo, err = chain.utxoStore.Get(fmt.Sprintf("%s_%d", closeHash, 1))
+	require.Nil(t, err)
+	assert.Equal(t, int64(640), utxo.Amount)
+	assert.Equal(t, payerKey.Public().Address().Bytes(), utxo.Address)
+}
+
+func TestChannelRefund(t *testing.T) {
+	var (
+		chain    = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = fundChannel(t, chain, payerKey, payeeKey, 3)
+	)
+
+	payer, err := channel.NewPayer(ch, payerKey)
+	require.Nil(t, err)
+
+	refund := payer.RefundTransaction()
+	// the next block is at height 2
+	require.NotNil(t, chain.ValidateTransaction(refund))
+
+	addBlockWithTx(t, chain)
+
+	// after the timeout the payee still can't spend the funding output alone
+	payeeRefund := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   ch.FundingTxHash,
+			PrevOutIndex: ch.FundingOutIndex,
+			PublicKey:    payeeKey.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{{Amount: 990, Address: payeeKey.Public().Address().Bytes()}},
+	}
+	payeeRefund.Inputs[0].Signature = types.SignTransaction(payeeKey, payeeRefund).Bytes()
+	require.NotNil(t, chain.ValidateTransaction(payeeRefund))
+
+	addBlockWithTx(t, chain, refund)
+}
+
+func TestChannelOutputValidation(t *testing.T) {
+	var (
+		chain = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		key   = crypto.GeneratePrivateKey()
+	)
+
+	sameKeys := types.NewChannelOutput(1000, key.Public(), key.Public(), 10)
+	require.NotNil(t, chain.ValidateTransaction(genesisSpend(t, chain, sameKeys)))
+
+	noTimeout := types.NewChannelOutput(1000, key.Public(), crypto.GeneratePrivateKey().Public(), 0)
+	require.NotNil(t, chain.ValidateTransaction(genesisSpend(t, chain, noTimeout)))
+}
@@ -0,0 +1,94 @@
+package node
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// evidenceWindow is the number of heights below the tip for which block
+// headers are kept to detect double signing.
+const evidenceWindow = 100
+
+// DoubleSignDetector remembers the headers it has seen per height and signer,
+// and reports when a signer shows up with a second, different header. Every
+// signer is only reported once, since the chain removes it from the validator
+// set after the first piece of evidence. Only headers of validators close to
+// the tip of the chain are kept, so peers can not fill it with headers signed
+// by throwaway keys or at made up heights.
+type DoubleSignDetector struct {
+	lock     sync.Mutex
+	chain    *Chain
+	headers  map[int32]map[string]*proto.SignedHeader
+	reported map[string]bool
+}
+
+func NewDoubleSignDetector(chain *Chain) *DoubleSignDetector {
+	return &DoubleSignDetector{
+		chain:    chain,
+		headers:  make(map[int32]map[string]*proto.SignedHeader),
+		reported: make(map[string]bool),
+	}
+}
+
+// Observe records the header of the block and returns evidence if its signer
+// already signed a different header at the same height.
+func (d *DoubleSignDetector) Observe(b *proto.Block) *proto.DoubleSignEvidence {
+	sh := types.SignedHeaderFromBlock(b)
+	if !types.VerifySignedHeader(sh) {
+		return nil
+	}
+
+	height := int(sh.Header.Height)
+	tip := d.chain.Height()
+	if height < tip-evidenceWindow || height > tip+1 {
+		return nil
+	}
+	if !d.chain.ValidatorsAt(height).Has(sh.PublicKey) {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	signer := hex.EncodeToString(sh.PublicKey)
+
+	if _, ok := d.headers[sh.Header.Height]; !ok {
+		d.headers[sh.Header.Height] = make(map[string]*proto.SignedHeader)
+	}
+
+	seen, ok := d.headers[sh.Header.Height][signer]
+	if !ok {
+		d.headers[sh.Header.Height][signer] = sh
+		return nil
+	}
+
+	if bytes.Equal(types.HashHeader(seen.Header), types.HashHeader(sh.Header)) {
+		return nil
+	}
+
+	if d.reported[signer] {
+		return nil
+	}
+	d.reported[signer] = true
+
+	return &proto.DoubleSignEvidence{
+		First:  seen,
+		Second: sh,
+	}
+}
+
+// Prune forgets all headers below the given height.
+func (d *DoubleSignDetector) Prune(height int32) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for h := range d.headers {
+		if h < height {
+			delete(d.headers, h)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// validatorSnapshot is the validator set in effect from height onwards, until
+// the next snapshot.
+type validatorSnapshot struct {
+	height int
+	set    *ValidatorSet
+}
+
+// ValidatorsAt returns a copy of the validator set that was allowed to sign the
+// block at the given height.
+func (c *Chain) ValidatorsAt(height int) *ValidatorSet {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.validatorsAt(height).Copy()
+}
+
+func (c *Chain) validatorsAt(height int) *ValidatorSet {
+	for i := len(c.validatorHistory) - 1; i > 0; i-- {
+		if c.validatorHistory[i].height <= height {
+			return c.validatorHistory[i].set
+		}
+	}
+	return c.validatorHistory[0].set
+}
+
+func (c *Chain) validateGovernance(gov *proto.GovernanceProposal) error {
+	change := gov.Change
+	if change == nil || len(change.Updates) == 0 {
+		return fmt.Errorf("governance proposal has no validator updates")
+	}
+
+	nextHeight := c.headers.Height() + 1
+	if int(change.ActivationHeight) <= nextHeight {
+		return fmt.Errorf("activation height %d must be above %d", change.ActivationHeight, nextHeight)
+	}
+
+	for _, update := range change.Updates {
+		if len(update.PublicKey) != crypto.PublicKeyLen {
+			return fmt.Errorf("invalid validator public key length %d", len(update.PublicKey))
+		}
+		if update.Bond < 0 {
+			return fmt.Errorf("negative validator bond %d", update.Bond)
+		}
+	}
+	if err := c.checkScheduledChanges([]*proto.ValidatorSetChange{change}); err != nil {
+		return err
+	}
+
+	if c.validators.Len() == 0 {
+		return fmt.Errorf("there are no validators to approve the proposal")
+	}
+
+	approvals := make(map[string]bool)
+	for _, sig := range gov.Signatures {
+		if !c.validators.Has(sig.PublicKey) {
+			continue
+		}
+		if !types.VerifyGovernanceSignature(change, sig) {
+			return fmt.Errorf("invalid governance signature from %x", sig.PublicKey)
+		}
+		approvals[hex.EncodeToString(sig.PublicKey)] = true
+	}
+
+	required := (c.validators.Len()*c.params.GovernanceThreshold + 99) / 100
+	// a threshold of zero must not let a proposal without approvals through
+	if required < 1 {
+		required = 1
+	}
+	if len(approvals) < required {
+		return fmt.Errorf("governance proposal has %d approvals, %d required", len(approvals), required)
+	}
+	return nil
+}
+
+// checkScheduledChanges checks that the changes are not scheduled already and
+// that the validator set doesn't become empty at any height once they are
+// applied together with the ones that are.
+func (c *Chain) checkScheduledChanges(changes []*proto.ValidatorSetChange) error {
+	var (
+		scheduled = make(map[string]bool)
+		pending   = make(map[int][]*proto.ValidatorSetChange, len(c.pendingChanges))
+	)
+	for height, atHeight := range c.pendingChanges {
+		for _, change := range atHeight {
+			scheduled[hex.EncodeToString(types.HashValidatorSetChange(change))] = true
+		}
+		pending[height] = atHeight[:len(atHeight):len(atHeight)]
+	}
+	for _, change := range changes {
+		hash := hex.EncodeToString(types.HashValidatorSetChange(change))
+		if scheduled[hash] {
+			return fmt.Errorf("governance proposal %s is already scheduled", hash)
+		}
+		scheduled[hash] = true
+		height := int(change.ActivationHeight)
+		pending[height] = append(pending[height], change)
+	}
+
+	heights := make([]int, 0, len(pending))
+	for height := range pending {
+		heights = append(heights, height)
+	}
+	sort.Ints(heights)
+
+	result := c.validators.Copy()
+	for _, height := range heights {
+		for _, change := range pending[height] {
+			for _, update := range change.Updates {
+				applyValidatorUpdate(result, update)
+			}
+		}
+		if result.Len() == 0 {
+			return fmt.Errorf("governance proposal would remove every validator at height %d", height)
+		}
+	}
+	return nil
+}
+
+func (c *Chain) scheduleValidatorSetChange(change *proto.ValidatorSetChange) {
+	height := int(change.ActivationHeight)
+	c.pendingChanges[height] = append(c.pendingChanges[height], change)
+}
+
+// advanceValidatorSet applies the changes scheduled for the block after height
+// and records a new snapshot if the set changed while connecting height.
+func (c *Chain) advanceValidatorSet(height int) {
+	next := height + 1
+	if changes, ok := c.pendingChanges[next]; ok {
+		for _, change := range changes {
+			for _, update := range change.Updates {
+				applyValidatorUpdate(c.validators, update)
+			}
+		}
+		delete(c.pendingChanges, next)
+		c.validatorsChanged = true
+	}
+
+	if !c.validatorsChanged {
+		return
+	}
+	c.validatorHistory = append(c.validatorHistory, &validatorSnapshot{
+		height: next,
+		set:    c.validators.Copy(),
+	})
+	c.validatorsChanged = false
+}
+
+// saveValidatorState returns an undo operation restoring the validator set,
+// its history, the scheduled changes and the slashed validators to their
+// current state.
+func (c *Chain) saveValidatorState() func() error {
+	var (
+		validators = c.validators.Copy()
+		changed    = c.validatorsChanged
+		historyLen = len(c.validatorHistory)
+		pending    = make(map[int][]*proto.ValidatorSetChange, len(c.pendingChanges))
+		slashed    = make(map[string]int64, len(c.slashed))
+	)
+	for height, changes := range c.pendingChanges {
+		pending[height] = changes[:len(changes):len(changes)]
+	}
+	for k, v := range c.slashed {
+		slashed[k] = v
+	}
+
+	return func() error {
+		c.validators = validators
+		c.validatorsChanged = changed
+		c.validatorHistory = c.validatorHistory[:historyLen]
+		c.pendingChanges = pending
+		c.slashed = slashed
+		return nil
+	}
+}
+
+func applyValidatorUpdate(set *ValidatorSet, update *proto.ValidatorUpdate) {
+	if update.Remove {
+		set.Remove(update.PublicKey)
+		return
+	}
+	set.Add(&Validator{
+		PublicKey: crypto.PublicKeyFromBytes(update.PublicKey),
+		Bond:      update.Bond,
+	})
+}
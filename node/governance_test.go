@@ -0,0 +1,165 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGovernanceValidatorSetChange(t *testing.T) {
+	var (
+		validators = []*crypto.PrivateKey{
+			crypto.GeneratePrivateKey(),
+			crypto.GeneratePrivateKey(),
+			crypto.GeneratePrivateKey(),
+		}
+		newValidator = crypto.GeneratePrivateKey()
+		params       = DefaultChainParams()
+	)
+	for _, v := range validators {
+		params.GenesisValidators = append(params.GenesisValidators, &Validator{PublicKey: v.Public(), Bond: 100})
+	}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	change := &proto.ValidatorSetChange{
+		ActivationHeight: 3,
+		Updates: []*proto.ValidatorUpdate{
+			{PublicKey: newValidator.Public().Bytes(), Bond: 50},
+			{PublicKey: validators[2].Public().Bytes(), Remove: true},
+		},
+	}
+
+	// a single approval out of three is below the threshold
+	tx := types.NewGovernanceTransaction(change, types.SignValidatorSetChange(validators[0], change))
+	require.NotNil(t, chain.ValidateTransaction(tx))
+
+	// approvals from non validators don't count
+	tx = types.NewGovernanceTransaction(change,
+		types.SignValidatorSetChange(validators[0], change),
+		types.SignValidatorSetChange(newValidator, change),
+	)
+	require.NotNil(t, chain.ValidateTransaction(tx))
+
+	tx = types.NewGovernanceTransaction(change,
+		types.SignValidatorSetChange(validators[0], change),
+		types.SignValidatorSetChange(validators[1], change),
+	)
+	require.Nil(t, chain.ValidateTransaction(tx))
+
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, tx)
+	types.SignBlock(validators[2], block)
+	require.Nil(t, chain.AddBlock(block))
+
+	// the change is not active yet at height 2
+	block = randomBlock(t, chain)
+	types.SignBlock(newValidator, block)
+	require.NotNil(t, chain.AddBlock(block))
+	types.SignBlock(validators[2], block)
+	require.Nil(t, chain.AddBlock(block))
+
+	// from height 3 on the new validator replaces the removed one
+	block = randomBlock(t, chain)
+	types.SignBlock(validators[2], block)
+	require.NotNil(t, chain.AddBlock(block))
+	types.SignBlock(newValidator, block)
+	require.Nil(t, chain.AddBlock(block))
+
+	assert.True(t, chain.ValidatorsAt(2).Has(validators[2].Public().Bytes()))
+	assert.False(t, chain.ValidatorsAt(2).Has(newValidator.Public().Bytes()))
+	assert.False(t, chain.ValidatorsAt(3).Has(validators[2].Public().Bytes()))
+	assert.True(t, chain.ValidatorsAt(3).Has(newValidator.Public().Bytes()))
+
+	// past blocks still validate against the set of their height
+	for height := 1; height <= chain.Height(); height++ {
+		b, err := chain.GetBlockByHeight(height)
+		require.Nil(t, err)
+		assert.True(t, chain.ValidatorsAt(height).Has(b.PublicKey))
+	}
+}
+
+func TestGovernanceActivationInThePast(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		params    = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	change := &proto.ValidatorSetChange{
+		ActivationHeight: 1,
+		Updates: []*proto.ValidatorUpdate{
+			{PublicKey: crypto.GeneratePrivateKey().Public().Bytes(), Bond: 50},
+		},
+	}
+	tx := types.NewGovernanceTransaction(change, types.SignValidatorSetChange(validator, change))
+	require.NotNil(t, chain.ValidateTransaction(tx))
+}
+
+func TestGovernanceNeedsAnApproval(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		params    = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	params.GovernanceThreshold = 0
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	change := &proto.ValidatorSetChange{
+		ActivationHeight: 3,
+		Updates: []*proto.ValidatorUpdate{
+			{PublicKey: crypto.GeneratePrivateKey().Public().Bytes(), Bond: 100},
+		},
+	}
+	require.NotNil(t, chain.ValidateTransaction(types.NewGovernanceTransaction(change)))
+	require.Nil(t, chain.ValidateTransaction(types.NewGovernanceTransaction(change, types.SignValidatorSetChange(validator, change))))
+}
+
+func TestGovernanceProposalsCanNotRemoveEveryValidator(t *testing.T) {
+	var (
+		validators = []*crypto.PrivateKey{crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()}
+		params     = DefaultChainParams()
+	)
+	for _, v := range validators {
+		params.GenesisValidators = append(params.GenesisValidators, &Validator{PublicKey: v.Public(), Bond: 100})
+	}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	removal := func(v *crypto.PrivateKey, height int32) *proto.Transaction {
+		change := &proto.ValidatorSetChange{
+			ActivationHeight: height,
+			Updates:          []*proto.ValidatorUpdate{{PublicKey: v.Public().Bytes(), Remove: true}},
+		}
+		return types.NewGovernanceTransaction(change,
+			types.SignValidatorSetChange(validators[0], change),
+			types.SignValidatorSetChange(validators[1], change),
+		)
+	}
+
+	// each removal is fine on its own, but not both in the same block
+	first, second := removal(validators[0], 5), removal(validators[1], 4)
+	require.Nil(t, chain.ValidateTransaction(first))
+	require.Nil(t, chain.ValidateTransaction(second))
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, first, second)
+	types.SignBlock(validators[0], block)
+	require.NotNil(t, chain.AddBlock(block))
+
+	block = randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, first)
+	types.SignBlock(validators[0], block)
+	require.Nil(t, chain.AddBlock(block))
+
+	// nor once the first one is scheduled, before or after its activation
+	assert.NotNil(t, chain.ValidateTransaction(second))
+	assert.NotNil(t, chain.ValidateTransaction(removal(validators[1], 8)))
+
+	// and a scheduled proposal can't be replayed
+	err := chain.ValidateTransaction(first)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already scheduled")
+}
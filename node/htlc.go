@@ -0,0 +1,41 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+type revealedPreimage struct {
+	preimage []byte
+	txHash   []byte
+}
+
+// GetPreimage returns the preimage revealed on chain for the HTLC hash, and the
+// hash of the transaction that revealed it.
+func (c *Chain) GetPreimage(hash []byte) ([]byte, []byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	revealed, ok := c.preimages[hex.EncodeToString(hash)]
+	if !ok {
+		return nil, nil, fmt.Errorf("no preimage revealed for hash %x", hash)
+	}
+	return revealed.preimage, revealed.txHash, nil
+}
+
+func (c *Chain) recordPreimage(undo *blockUndo, hash, preimage, txHash []byte) {
+	key := hex.EncodeToString(hash)
+	prev, existed := c.preimages[key]
+	c.preimages[key] = &revealedPreimage{
+		preimage: preimage,
+		txHash:   txHash,
+	}
+	undo.add(func() error {
+		if existed {
+			c.preimages[key] = prev
+		} else {
+			delete(c.preimages, key)
+		}
+		return nil
+	})
+}
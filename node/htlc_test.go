@@ -0,0 +1,85 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func htlcSpend(fundTx *proto.Transaction, spender *crypto.PrivateKey, preimage []byte) *proto.Transaction {
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(fundTx),
+			PrevOutIndex: 0,
+			PublicKey:    spender.Public().Bytes(),
+			Preimage:     preimage,
+		}},
+		Outputs: []*proto.TxOutput{{
+			Amount:  1000,
+			Address: spender.Public().Address().Bytes(),
+		}},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(spender, tx).Bytes()
+	return tx
+}
+
+func TestHTLCClaim(t *testing.T) {
+	var (
+		node      = NewNode(ServerConfig{})
+		chain     = node.chain
+		sender    = crypto.GeneratePrivateKey()
+		recipient = crypto.GeneratePrivateKey()
+		preimage  = util.RandomHash()
+		hash      = sha256.Sum256(preimage)
+	)
+
+	fundTx := genesisSpend(t, chain, types.NewHTLCOutput(1000, hash[:], recipient.Public().Address(), sender.Public().Address(), 5))
+	addBlockWithTx(t, chain, fundTx)
+
+	_, err := NewQueryServer(node).GetPreimage(context.Background(), &proto.PreimageRequest{Hash: hash[:]})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// the sender can't refund before the timeout
+	require.NotNil(t, chain.ValidateTransaction(htlcSpend(fundTx, sender, nil)))
+	// only the recipient can use the preimage
+	require.NotNil(t, chain.ValidateTransaction(htlcSpend(fundTx, sender, preimage)))
+	// the preimage has to match
+	require.NotNil(t, chain.ValidateTransaction(htlcSpend(fundTx, recipient, util.RandomHash())))
+
+	claim := htlcSpend(fundTx, recipient, preimage)
+	addBlockWithTx(t, chain, claim)
+
+	resp, err := NewQueryServer(node).GetPreimage(context.Background(), &proto.PreimageRequest{Hash: hash[:]})
+	require.Nil(t, err)
+	assert.Equal(t, preimage, resp.Preimage)
+	assert.Equal(t, types.HashTransaction(claim), resp.TxHash)
+}
+
+func TestHTLCRefund(t *testing.T) {
+	var (
+		chain     = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		sender    = crypto.GeneratePrivateKey()
+		recipient = crypto.GeneratePrivateKey()
+		preimage  = util.RandomHash()
+		hash      = sha256.Sum256(preimage)
+	)
+
+	fundTx := genesisSpend(t, chain, types.NewHTLCOutput(1000, hash[:], recipient.Public().Address(), sender.Public().Address(), 3))
+	addBlockWithTx(t, chain, fundTx)
+	addBlockWithTx(t, chain)
+
+	// the next block is at the timeout height
+	require.NotNil(t, chain.ValidateTransaction(htlcSpend(fundTx, recipient, preimage)))
+	require.NotNil(t, chain.ValidateTransaction(htlcSpend(fundTx, recipient, nil)))
+	addBlockWithTx(t, chain, htlcSpend(fundTx, sender, nil))
+}
@@ -0,0 +1,84 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendMultisigOutput(t *testing.T) {
+	var (
+		chain   = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey  = crypto.NewPrivateKeyFromSeedString(godSeed)
+		signers = []*crypto.PrivateKey{
+			crypto.GeneratePrivateKey(),
+			crypto.GeneratePrivateKey(),
+			crypto.GeneratePrivateKey(),
+		}
+	)
+
+	prevTx, err := chain.txStore.Get(genesisTxHash(t, chain))
+	require.Nil(t, err)
+
+	output := types.NewMultisigOutput(1000, 2, signers[0].Public(), signers[1].Public(), signers[2].Public())
+	fundTx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(prevTx),
+			PrevOutIndex: 0,
+			PublicKey:    godKey.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{output},
+	}
+	fundTx.Inputs[0].Signature = types.SignTransaction(godKey, fundTx).Bytes()
+
+	// the address has to commit to the lock
+	tampered := types.NewMultisigOutput(1000, 1, signers[0].Public(), signers[1].Public(), signers[2].Public())
+	tampered.Address = output.Address
+	require.NotNil(t, chain.validateOutput(tampered, 1))
+
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, fundTx)
+	types.SignBlock(godKey, block)
+	require.Nil(t, chain.AddBlock(block))
+
+	spendTx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(fundTx),
+			PrevOutIndex: 0,
+		}},
+		Outputs: []*proto.TxOutput{{
+			Amount:  1000,
+			Address: godKey.Public().Address().Bytes(),
+		}},
+	}
+
+	sigHash := types.SigHash(spendTx)
+	sign := func(keys ...*crypto.PrivateKey) [][]byte {
+		sigs := make([][]byte, len(output.Multisig.PublicKeys))
+		for _, k := range keys {
+			if i := types.MultisigKeyIndex(output.Multisig, k.Public()); i >= 0 {
+				sigs[i] = k.Sign(sigHash).Bytes()
+			}
+		}
+		return sigs
+	}
+
+	spendTx.Inputs[0].Signatures = sign(signers[0])
+	require.NotNil(t, chain.ValidateTransaction(spendTx))
+
+	spendTx.Inputs[0].Signatures = sign(signers[0], crypto.GeneratePrivateKey())
+	require.NotNil(t, chain.ValidateTransaction(spendTx))
+
+	spendTx.Inputs[0].Signatures = sign(signers[0], signers[2])
+	require.Nil(t, chain.ValidateTransaction(spendTx))
+
+	block = randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, spendTx)
+	types.SignBlock(godKey, block)
+	require.Nil(t, chain.AddBlock(block))
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// NameRecord is the state of a registered name.
+type NameRecord struct {
+	Address []byte
+	// ExpiresAt is the first height at which the name is no longer
+	// registered.
+	ExpiresAt int
+}
+
+// ResolveName returns the record of the name, if it is registered and not
+// expired at the next block.
+func (c *Chain) ResolveName(name string) (*NameRecord, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	record, ok := c.names[name]
+	if !ok || record.ExpiresAt <= c.headers.Height()+1 {
+		return nil, fmt.Errorf("name %q is not registered", name)
+	}
+	return record, nil
+}
+
+func (c *Chain) validateNameOperation(tx *proto.Transaction, op *proto.NameOperation, height int) error {
+	if !c.params.IsActive(ForkNames, height) {
+		return fmt.Errorf("names are not active at height %d", height)
+	}
+	if err := types.ValidateName(op.Name); err != nil {
+		return err
+	}
+	if len(tx.Inputs) == 0 {
+		return fmt.Errorf("name operation must spend an input")
+	}
+
+	record, ok := c.names[op.Name]
+	active := ok && height < record.ExpiresAt
+
+	switch op.Type {
+	case proto.NameOperation_REGISTER:
+		if active {
+			return fmt.Errorf("name %q is already registered", op.Name)
+		}
+		if len(op.Address) != crypto.AddressLen {
+			return fmt.Errorf("invalid name address length %d", len(op.Address))
+		}
+		return nil
+	case proto.NameOperation_RENEW, proto.NameOperation_TRANSFER:
+		if !active {
+			return fmt.Errorf("name %q is not registered", op.Name)
+		}
+		if err := checkNameOwner(tx, record); err != nil {
+			return err
+		}
+		if op.Type == proto.NameOperation_TRANSFER && len(op.Address) != crypto.AddressLen {
+			return fmt.Errorf("invalid name address length %d", len(op.Address))
+		}
+		return nil
+	case proto.NameOperation_EXPIRE:
+		if !ok {
+			return fmt.Errorf("name %q is not registered", op.Name)
+		}
+		if active {
+			return fmt.Errorf("name %q does not expire before height %d", op.Name, record.ExpiresAt)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown name operation %d", op.Type)
+}
+
+// checkNameOwner checks that the first input of the transaction is signed by
+// the key behind the address owning the name. The signature itself is verified
+// with the other input signatures.
+func checkNameOwner(tx *proto.Transaction, record *NameRecord) error {
+	input := tx.Inputs[0]
+	if len(input.Signature) == 0 || len(input.UnlockScript) > 0 || len(input.Signatures) > 0 {
+		return fmt.Errorf("first input of a name operation must carry the owner signature")
+	}
+	pubKey, err := crypto.ParsePublicKey(input.PublicKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pubKey.Address().Bytes(), record.Address) {
+		return fmt.Errorf("name operation is not signed by the owner")
+	}
+	return nil
+}
+
+func (c *Chain) applyNameOperation(undo *blockUndo, op *proto.NameOperation, height int) {
+	prev, existed := c.names[op.Name]
+
+	switch op.Type {
+	case proto.NameOperation_REGISTER:
+		c.names[op.Name] = &NameRecord{
+			Address:   op.Address,
+			ExpiresAt: height + c.params.NameRegistrationPeriod,
+		}
+	case proto.NameOperation_RENEW:
+		c.names[op.Name] = &NameRecord{
+			Address:   prev.Address,
+			ExpiresAt: prev.ExpiresAt + c.params.NameRegistrationPeriod,
+		}
+	case proto.NameOperation_TRANSFER:
+		c.names[op.Name] = &NameRecord{
+			Address:   op.Address,
+			ExpiresAt: prev.ExpiresAt,
+		}
+	case proto.NameOperation_EXPIRE:
+		delete(c.names, op.Name)
+	}
+
+	undo.add(func() error {
+		if existed {
+			c.names[op.Name] = prev
+		} else {
+			delete(c.names, op.Name)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,217 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nameTx spends output index of prev, owned by key, back to key and carries
+// the name operation.
+func nameTx(prev *proto.Transaction, index uint32, key *crypto.PrivateKey, op *proto.NameOperation) *proto.Transaction {
+	tx := types.NewNameTransaction(op, &proto.TxInput{
+		PrevTxHash:   types.HashTransaction(prev),
+		PrevOutIndex: index,
+		PublicKey:    key.Public().Bytes(),
+	})
+	tx.Outputs = []*proto.TxOutput{{
+		Amount:  prev.Outputs[index].Amount,
+		Address: key.Public().Address().Bytes(),
+	}}
+	tx.Inputs[0].Signature = types.SignTransaction(key, tx).Bytes()
+	return tx
+}
+
+func TestNameRegistry(t *testing.T) {
+	params := DefaultChainParams()
+	params.NameRegistrationPeriod = 5
+
+	var (
+		node   = NewNode(ServerConfig{})
+		chain  = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		alice  = crypto.GeneratePrivateKey()
+		bob    = crypto.GeneratePrivateKey()
+	)
+	node.chain = chain
+
+	funding := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 250, Address: alice.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 250, Address: bob.Public().Address().Bytes()},
+	)
+	addBlockWithTx(t, chain, funding)
+
+	register := &proto.NameOperation{
+		Type:    proto.NameOperation_REGISTER,
+		Name:    "alice",
+		Address: alice.Public().Address().Bytes(),
+	}
+	require.NotNil(t, chain.ValidateTransaction(nameTx(funding, 0, godKey, &proto.NameOperation{
+		Type: proto.NameOperation_REGISTER, Name: "Alice!", Address: alice.Public().Address().Bytes(),
+	})))
+	registerTx := nameTx(funding, 0, godKey, register)
+	require.Nil(t, chain.ValidateTransaction(registerTx))
+	addBlockWithTx(t, chain, registerTx)
+
+	resp, err := NewQueryServer(node).ResolveName(context.Background(), &proto.ResolveNameRequest{Name: "alice"})
+	require.Nil(t, err)
+	assert.Equal(t, alice.Public().Address().Bytes(), resp.Address)
+	assert.Equal(t, int32(2+5), resp.ExpiresAt)
+
+	// taken while registered
+	require.NotNil(t, chain.ValidateTransaction(nameTx(funding, 2, bob, &proto.NameOperation{
+		Type: proto.NameOperation_REGISTER, Name: "alice", Address: bob.Public().Address().Bytes(),
+	})))
+
+	// only the owner can transfer
+	transfer := &proto.NameOperation{
+		Type:    proto.NameOperation_TRANSFER,
+		Name:    "alice",
+		Address: bob.Public().Address().Bytes(),
+	}
+	require.NotNil(t, chain.ValidateTransaction(nameTx(funding, 2, bob, transfer)))
+
+	renewTx := nameTx(funding, 1, alice, &proto.NameOperation{Type: proto.NameOperation_RENEW, Name: "alice"})
+	require.Nil(t, chain.ValidateTransaction(renewTx))
+	addBlockWithTx(t, chain, renewTx)
+
+	record, err := chain.ResolveName("alice")
+	require.Nil(t, err)
+	assert.Equal(t, 12, record.ExpiresAt)
+
+	transferTx := nameTx(renewTx, 0, alice, transfer)
+	require.Nil(t, chain.ValidateTransaction(transferTx))
+	addBlockWithTx(t, chain, transferTx)
+
+	record, err = chain.ResolveName("alice")
+	require.Nil(t, err)
+	assert.Equal(t, bob.Public().Address().Bytes(), record.Address)
+
+	// undo the transfer and renewal
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	record, err = chain.ResolveName("alice")
+	require.Nil(t, err)
+	assert.Equal(t, alice.Public().Address().Bytes(), record.Address)
+	assert.Equal(t, 7, record.ExpiresAt)
+
+	// let the name expire, then anyone can register it
+	for chain.Height() < 6 {
+		addBlockWithTx(t, chain)
+	}
+	_, err = chain.ResolveName("alice")
+	require.NotNil(t, err)
+	_, err = NewQueryServer(node).ResolveName(context.Background(), &proto.ResolveNameRequest{Name: "alice"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	bobTx := nameTx(funding, 2, bob, &proto.NameOperation{
+		Type: proto.NameOperation_REGISTER, Name: "alice", Address: bob.Public().Address().Bytes(),
+	})
+	require.Nil(t, chain.ValidateTransaction(bobTx))
+	addBlockWithTx(t, chain, bobTx)
+	record, err = chain.ResolveName("alice")
+	require.Nil(t, err)
+	assert.Equal(t, bob.Public().Address().Bytes(), record.Address)
+}
+
+func TestNameExpiry(t *testing.T) {
+	params := DefaultChainParams()
+	params.NameRegistrationPeriod = 3
+
+	var (
+		chain  = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		bob    = crypto.GeneratePrivateKey()
+	)
+
+	funding := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 500, Address: bob.Public().Address().Bytes()},
+	)
+	addBlockWithTx(t, chain, funding)
+
+	expire := &proto.NameOperation{Type: proto.NameOperation_EXPIRE, Name: "alice"}
+	require.NotNil(t, chain.ValidateTransaction(nameTx(funding, 1, bob, expire)))
+
+	addBlockWithTx(t, chain, nameTx(funding, 0, godKey, &proto.NameOperation{
+		Type: proto.NameOperation_REGISTER, Name: "alice", Address: godKey.Public().Address().Bytes(),
+	}))
+
+	// a registered name can't be expired early
+	expireTx := nameTx(funding, 1, bob, expire)
+	require.NotNil(t, chain.ValidateTransaction(expireTx))
+
+	// once expired anyone can remove it from the index
+	for chain.Height() < 4 {
+		addBlockWithTx(t, chain)
+	}
+	require.Nil(t, chain.ValidateTransaction(expireTx))
+	addBlockWithTx(t, chain, expireTx)
+	_, ok := chain.names["alice"]
+	assert.False(t, ok)
+
+	_, err := chain.DisconnectTip()
+	require.Nil(t, err)
+	record, ok := chain.names["alice"]
+	require.True(t, ok)
+	assert.Equal(t, godKey.Public().Address().Bytes(), record.Address)
+}
+
+func TestNameChangedTwiceInBlock(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+	)
+
+	funding := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+	)
+	addBlockWithTx(t, chain, funding)
+
+	op := &proto.NameOperation{
+		Type:    proto.NameOperation_REGISTER,
+		Name:    "shop",
+		Address: godKey.Public().Address().Bytes(),
+	}
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, nameTx(funding, 0, godKey, op), nameTx(funding, 1, godKey, op))
+	types.SignBlock(godKey, block)
+	require.NotNil(t, chain.AddBlock(block))
+}
+
+func TestCreateBlockDefersSecondNameOperation(t *testing.T) {
+	var (
+		node   = NewNode(ServerConfig{PrivateKey: crypto.GeneratePrivateKey()})
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+	)
+
+	funding := genesisSpend(t, node.chain,
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+		&proto.TxOutput{Amount: 500, Address: godKey.Public().Address().Bytes()},
+	)
+	addBlockWithTx(t, node.chain, funding)
+
+	op := &proto.NameOperation{
+		Type:    proto.NameOperation_REGISTER,
+		Name:    "shop",
+		Address: godKey.Public().Address().Bytes(),
+	}
+	require.True(t, node.mempool.Add(nameTx(funding, 0, godKey, op)))
+	require.True(t, node.mempool.Add(nameTx(funding, 1, godKey, op)))
+
+	block, err := node.createBlock()
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(block.Transactions))
+	assert.Equal(t, 1, node.mempool.Len())
+}
@@ -1,19 +1,25 @@
 package node
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"fmt"
 	"net"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/mhg14/ChlockBane/crypto"
 	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/signer"
 	"github.com/mhg14/ChlockBane/types"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 const blockTime = time.Second * 5
@@ -45,6 +51,24 @@ func (mp *Mempool) Clear() []*proto.Transaction {
 	return txx
 }
 
+// Take removes and returns the transactions accepted by the filter, leaving the
+// others in the pool.
+func (mp *Mempool) Take(filter func(*proto.Transaction) bool) []*proto.Transaction {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	txx := []*proto.Transaction{}
+	for k, v := range mp.txx {
+		if !filter(v) {
+			continue
+		}
+		delete(mp.txx, k)
+		txx = append(txx, v)
+	}
+
+	return txx
+}
+
 func (mp *Mempool) Len() int {
 	mp.lock.RLock()
 	defer mp.lock.RUnlock()
@@ -60,6 +84,14 @@ func (mp *Mempool) Has(tx *proto.Transaction) bool {
 	return ok
 }
 
+// Get returns the transaction with the given hash if it is in the pool.
+func (mp *Mempool) Get(hash []byte) (*proto.Transaction, bool) {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	tx, ok := mp.txx[hex.EncodeToString(hash)]
+	return tx, ok
+}
+
 func (mp *Mempool) Add(tx *proto.Transaction) bool {
 	if mp.Has(tx) {
 		return false
@@ -79,8 +111,14 @@ type Node struct {
 	peerLock sync.RWMutex
 	peers    map[proto.NodeClient]*proto.Version
 
-	logger  *zap.SugaredLogger
-	mempool *Mempool
+	logger    *zap.SugaredLogger
+	mempool   *Mempool
+	chain     *Chain
+	doubleSig *DoubleSignDetector
+	// signed is a block this node signed that didn't make it into the chain.
+	// The signer refuses any other block at its height, so it is retried
+	// until the chain moves past it.
+	signed *proto.Block
 	ServerConfig
 }
 
@@ -88,6 +126,23 @@ type ServerConfig struct {
 	Version    string
 	ListenAddr string
 	PrivateKey *crypto.PrivateKey
+	// KeystorePath, when set, is an encrypted keystore file the validator
+	// key is loaded from at startup. It can't be combined with PrivateKey,
+	// Signer or RemoteSignerPath.
+	KeystorePath     string
+	KeystorePassword string
+	// Signer signs the blocks of a validator. When nil, a local signer for
+	// PrivateKey is used.
+	Signer signer.Signer
+	// RemoteSignerPath, when set, is the unix socket of a signer daemon the
+	// node signs its blocks with instead of holding the key itself.
+	RemoteSignerPath string
+	// ChainParams are the consensus rules and genesis validators of the
+	// chain. When nil, DefaultChainParams is used.
+	ChainParams *ChainParams
+	// AddressIndex makes the node index the outputs and transactions of
+	// every address, so it can answer address queries.
+	AddressIndex bool
 }
 
 func NewNode(cfg ServerConfig) *Node {
@@ -95,30 +150,72 @@ func NewNode(cfg ServerConfig) *Node {
 	loggerConfig.EncoderConfig.TimeKey = ""
 	logger, _ := loggerConfig.Build()
 
+	if cfg.Signer == nil && cfg.PrivateKey != nil {
+		// an in memory signer can't fail to load its state
+		cfg.Signer, _ = signer.NewLocalSigner(cfg.PrivateKey, "")
+	}
+
+	params := cfg.ChainParams
+	if params == nil {
+		params = DefaultChainParams()
+	}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+	if cfg.AddressIndex {
+		// only the genesis block is indexed here, which can't fail
+		chain.EnableAddressIndex()
+	}
+
 	return &Node{
 		peers:        make(map[proto.NodeClient]*proto.Version),
 		logger:       logger.Sugar(),
 		mempool:      NewMempool(),
+		chain:        chain,
+		doubleSig:    NewDoubleSignDetector(chain),
 		ServerConfig: cfg,
 	}
 }
 
 func (n *Node) Start(listenAddr string, bootstrapNodes []string) error {
 	n.ListenAddr = listenAddr
-	grpcServer := grpc.NewServer(grpc.EmptyServerOption{})
+
+	if n.KeystorePath != "" {
+		if n.PrivateKey != nil || n.Signer != nil || n.RemoteSignerPath != "" {
+			return fmt.Errorf("keystore %s can't be used together with another validator key or signer", n.KeystorePath)
+		}
+		privKey, err := crypto.UnlockKeystore(n.KeystorePath, n.KeystorePassword)
+		if err != nil {
+			return fmt.Errorf("unlocking keystore %s: %w", n.KeystorePath, err)
+		}
+		n.PrivateKey = privKey
+		n.Signer, _ = signer.NewLocalSigner(privKey, "")
+		n.logger.Infow("loaded validator key from keystore", "address", privKey.Public().Address())
+	}
+
+	if n.RemoteSignerPath != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		remote, err := signer.DialRemoteSigner(ctx, n.RemoteSignerPath)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("connecting to signer %s: %w", n.RemoteSignerPath, err)
+		}
+		n.Signer = remote
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(n.recoverPanic))
 
 	ln, err := net.Listen("tcp", listenAddr)
 	if err != nil {
 		return err
 	}
 	proto.RegisterNodeServer(grpcServer, n)
+	proto.RegisterQueryServer(grpcServer, NewQueryServer(n))
 	n.logger.Infow("node started...", "port", n.ListenAddr)
 
 	if len(bootstrapNodes) > 0 {
 		go n.bootstrapNetwork(bootstrapNodes)
 	}
 
-	if n.PrivateKey != nil {
+	if n.Signer != nil {
 		go n.validatorLoop()
 	}
 
@@ -129,6 +226,17 @@ func (n *Node) HandleTransaction(ctx context.Context, tx *proto.Transaction) (*p
 	peer, _ := peer.FromContext(ctx)
 	hash := hex.EncodeToString(types.HashTransaction(tx))
 
+	if !types.IsKnownTransactionVersion(tx.Version) {
+		n.logger.Warnw("received transaction with unknown version, this node may need an upgrade", "version", tx.Version, "hash", hash)
+	}
+
+	// Validating on admission keeps invalid transactions out of the mempool
+	// and caches the signatures for when the transaction shows up in a block.
+	if err := n.chain.ValidateTransaction(tx); err != nil {
+		n.logger.Debugw("rejected transaction", "from", peer.Addr, "hash", hash, "err", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	if n.mempool.Add(tx) {
 		n.logger.Debugw("reciecved tx", "we", n.ListenAddr, "from", peer.Addr, "hash", hash)
 
@@ -142,6 +250,84 @@ func (n *Node) HandleTransaction(ctx context.Context, tx *proto.Transaction) (*p
 	return &proto.Ack{}, nil
 }
 
+func (n *Node) HandleBlock(ctx context.Context, b *proto.Block) (*proto.Ack, error) {
+	peer, _ := peer.FromContext(ctx)
+	if b.Header == nil {
+		return nil, status.Error(codes.InvalidArgument, "block has no header")
+	}
+	hash := types.HashBlock(b)
+
+	if !types.IsKnownBlockVersion(b.Header.Version) {
+		n.logger.Warnw("received block with unknown version, this node may need an upgrade",
+			"version", b.Header.Version,
+			"height", b.Header.Height,
+			"hash", hex.EncodeToString(hash),
+		)
+	}
+
+	if ev := n.doubleSig.Observe(b); ev != nil {
+		n.reportDoubleSign(ev)
+	}
+
+	if n.chain.HasBlock(hash) {
+		return &proto.Ack{}, nil
+	}
+
+	if err := n.chain.AddBlock(b); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	n.logger.Debugw("recieved block", "we", n.ListenAddr, "from", peer.Addr, "hash", hex.EncodeToString(hash), "height", b.Header.Height)
+	n.doubleSig.Prune(int32(n.chain.Height() - evidenceWindow))
+
+	go func() {
+		if err := n.broadcast(b); err != nil {
+			n.logger.Errorw("broadcast error", "err", err)
+		}
+	}()
+
+	return &proto.Ack{}, nil
+}
+
+// recoverPanic keeps a malformed request from taking the node down. The panic
+// is logged and the caller gets an internal error back.
+func (n *Node) recoverPanic(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			n.logger.Errorw("recovered from panic in rpc handler",
+				"method", info.FullMethod,
+				"panic", r,
+				"stack", string(debug.Stack()),
+			)
+			err = status.Errorf(codes.Internal, "internal error handling %s", info.FullMethod)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// reportDoubleSign turns the evidence into a transaction and gossips it, so the
+// next block can slash the offending validator.
+func (n *Node) reportDoubleSign(ev *proto.DoubleSignEvidence) {
+	n.logger.Warnw("validator signed two blocks at the same height",
+		"validator", hex.EncodeToString(ev.First.PublicKey),
+		"height", ev.First.Header.Height,
+	)
+
+	tx := types.NewDoubleSignTransaction(ev.First, ev.Second)
+	if err := n.chain.ValidateTransaction(tx); err != nil {
+		n.logger.Debugw("ignoring double sign evidence", "err", err)
+		return
+	}
+	if !n.mempool.Add(tx) {
+		return
+	}
+
+	go func() {
+		if err := n.broadcast(tx); err != nil {
+			n.logger.Errorw("broadcast error", "err", err)
+		}
+	}()
+}
+
 func (n *Node) broadcast(msg any) error {
 	for peer := range n.peers {
 		switch v := msg.(type) {
@@ -150,6 +336,11 @@ func (n *Node) broadcast(msg any) error {
 			if err != nil {
 				return err
 			}
+		case *proto.Block:
+			_, err := peer.HandleBlock(context.Background(), v)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -167,17 +358,145 @@ func (n *Node) Handshake(ctx context.Context, v *proto.Version) (*proto.Version,
 }
 
 func (n *Node) validatorLoop() {
-	n.logger.Infow("starting validator loop", "pubKey", n.PrivateKey.Public(), "blockTime", blockTime)
+	n.logger.Infow("starting validator loop", "pubKey", hex.EncodeToString(n.Signer.PublicKey().Bytes()), "blockTime", blockTime)
 	ticker := time.NewTicker(blockTime)
 	for {
 		<-ticker.C
 
-		txx := n.mempool.Clear()
+		block, err := n.createBlock()
+		if err != nil {
+			n.logger.Errorw("failed to create block", "err", err)
+			continue
+		}
+
+		n.logger.Debugw("created new block",
+			"height", block.Header.Height,
+			"hash", hex.EncodeToString(types.HashBlock(block)),
+			"lenTx", len(block.Transactions),
+		)
+
+		go func() {
+			if err := n.broadcast(block); err != nil {
+				n.logger.Errorw("broadcast error", "err", err)
+			}
+		}()
+	}
+}
+
+// createBlock builds a block on top of the chain from the final transactions in
+// the mempool, signs it and adds it to the chain. Transactions that are not
+// final yet stay in the mempool, invalid ones are dropped.
+func (n *Node) createBlock() (*proto.Block, error) {
+	prevBlock, err := n.chain.GetBlockByHeight(n.chain.Height())
+	if err != nil {
+		return nil, err
+	}
+	var (
+		height   = prevBlock.Header.Height + 1
+		prevHash = types.HashBlock(prevBlock)
+	)
+
+	if n.signed != nil {
+		if n.signed.Header.Height == height && bytes.Equal(n.signed.Header.PrevHash, prevHash) {
+			return n.addSignedBlock(n.signed)
+		}
+		n.restoreTransactions(n.signed)
+		n.signed = nil
+	}
+
+	pubKey := n.Signer.PublicKey().Bytes()
+	validators := n.chain.ValidatorsAt(int(height))
+	if validators.Len() > 0 && !validators.Has(pubKey) {
+		return nil, fmt.Errorf("signer %x is not a validator at height %d", pubKey, height)
+	}
+
+	block := &proto.Block{
+		Header: &proto.Header{
+			Version:   types.BlockVersion,
+			Height:    height,
+			PrevHash:  prevHash,
+			Timestamp: time.Now().UnixNano(),
+		},
+	}
+
+	var (
+		spent    = make(map[string]bool)
+		names    = make(map[string]bool)
+		deferred = []*proto.Transaction{}
+	)
+	for _, tx := range n.mempool.Take(n.chain.IsFinal) {
+		if err := n.chain.ValidateTransaction(tx); err != nil {
+			n.logger.Debugw("dropping invalid tx", "hash", hex.EncodeToString(types.HashTransaction(tx)), "err", err)
+			continue
+		}
+		// a name can only change once per block, later operations on it
+		// wait for the next one
+		if op := tx.GetName(); op != nil && names[op.Name] {
+			deferred = append(deferred, tx)
+			continue
+		}
+		if conflictsWith(tx, spent) {
+			n.logger.Debugw("dropping double spending tx", "hash", hex.EncodeToString(types.HashTransaction(tx)))
+			continue
+		}
+		if op := tx.GetName(); op != nil {
+			names[op.Name] = true
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	for _, tx := range deferred {
+		n.mempool.Add(tx)
+	}
 
-		n.logger.Debugw("time to create a new block", "lenTx", len(txx))
+	// the signer refuses a second block at the same height, so the block
+	// has to be known good before it is signed
+	if err := n.chain.ValidateUnsignedBlock(block); err != nil {
+		n.restoreTransactions(block)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blockTime)
+	defer cancel()
+	if err := signer.SignBlock(ctx, n.Signer, block); err != nil {
+		n.restoreTransactions(block)
+		return nil, err
+	}
+	n.signed = block
+	return n.addSignedBlock(block)
+}
+
+// addSignedBlock adds a block signed by this node to the chain. The block is
+// kept for a retry when the chain rejects it.
+func (n *Node) addSignedBlock(block *proto.Block) (*proto.Block, error) {
+	if err := n.chain.AddBlock(block); err != nil {
+		return nil, err
+	}
+	n.signed = nil
+	return block, nil
+}
 
+// restoreTransactions puts the transactions of a block that didn't make it into
+// the chain back into the mempool, for the next block.
+func (n *Node) restoreTransactions(block *proto.Block) {
+	for _, tx := range block.Transactions {
+		n.mempool.Add(tx)
+	}
+}
 
+// conflictsWith reports whether the transaction spends an output that is
+// already in spent, and otherwise adds its inputs to spent.
+func conflictsWith(tx *proto.Transaction, spent map[string]bool) bool {
+	keys := make([]string, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		keys[i] = fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+		if spent[keys[i]] {
+			return true
+		}
+	}
+	for _, key := range keys {
+		spent[key] = true
 	}
+	return false
 }
 
 func (n *Node) bootstrapNetwork(addr []string) error {
@@ -262,7 +581,7 @@ func (n *Node) getPeerList() []string {
 func (n *Node) getVersion() *proto.Version {
 	return &proto.Version{
 		Version:    "ChlockBane-0.1",
-		Height:     0,
+		Height:     int32(n.chain.Height()),
 		ListenAddr: n.ListenAddr,
 		PeerList:   n.getPeerList(),
 	}
@@ -0,0 +1,199 @@
+package node
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext() context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3000},
+	})
+}
+
+func TestHandleMalformedTransaction(t *testing.T) {
+	n := NewNode(ServerConfig{Version: "test"})
+	privKey := crypto.GeneratePrivateKey()
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash: util.RandomHash(),
+			PublicKey:  privKey.Public().Bytes()[:10],
+		}},
+		Outputs: []*proto.TxOutput{{Amount: 1, Address: privKey.Public().Address().Bytes()}},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(privKey, tx).Bytes()
+
+	_, err := n.HandleTransaction(peerContext(), tx)
+	require.NotNil(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 0, n.mempool.Len())
+
+	tx.Inputs[0].Signature = []byte{1, 2, 3}
+	_, err = n.HandleTransaction(peerContext(), tx)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHandleBlockWithoutHeader(t *testing.T) {
+	n := NewNode(ServerConfig{Version: "test"})
+
+	_, err := n.HandleBlock(peerContext(), &proto.Block{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	b := randomBlock(t, n.chain)
+	b.PublicKey = b.PublicKey[:5]
+	_, err = n.HandleBlock(peerContext(), b)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRecoverPanic(t *testing.T) {
+	n := NewNode(ServerConfig{Version: "test"})
+	info := &grpc.UnaryServerInfo{FullMethod: "/Node/HandleTransaction"}
+
+	_, err := n.recoverPanic(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	resp, err := n.recoverPanic(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return &proto.Ack{}, nil
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, resp)
+}
+
+func TestHandleTransactionValidates(t *testing.T) {
+	n := NewNode(ServerConfig{Version: "test"})
+
+	// spends an output that doesn't exist
+	_, err := n.HandleTransaction(peerContext(), signedTx(t))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 0, n.mempool.Len())
+
+	godKey := crypto.NewPrivateKeyFromSeedString(godSeed)
+	tx := genesisSpend(t, n.chain, &proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()})
+	_, err = n.HandleTransaction(peerContext(), tx)
+	require.Nil(t, err)
+	assert.Equal(t, 1, n.mempool.Len())
+}
+
+func TestCreateBlockRestoresTransactions(t *testing.T) {
+	var (
+		node   = NewNode(ServerConfig{PrivateKey: crypto.GeneratePrivateKey()})
+		params = DefaultChainParams()
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+	)
+	// the node's key is not a validator, so its blocks are rejected
+	params.GenesisValidators = []*Validator{{PublicKey: crypto.GeneratePrivateKey().Public(), Bond: 100}}
+	node.chain = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	tx := genesisSpend(t, node.chain, &proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()})
+	require.True(t, node.mempool.Add(tx))
+
+	_, err := node.createBlock()
+	require.NotNil(t, err)
+	assert.True(t, node.mempool.Has(tx))
+	assert.Equal(t, 0, node.chain.Height())
+}
+
+func TestStartRejectsKeystoreWithPrivateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.Nil(t, crypto.CreateKeystore(path, crypto.GeneratePrivateKey(), "password", crypto.LightScryptN))
+
+	node := NewNode(ServerConfig{
+		PrivateKey:       crypto.GeneratePrivateKey(),
+		KeystorePath:     path,
+		KeystorePassword: "password",
+	})
+	require.NotNil(t, node.Start("127.0.0.1:0", nil))
+}
+
+func TestNewNodeUsesChainParams(t *testing.T) {
+	validator := crypto.GeneratePrivateKey()
+	params := DefaultChainParams()
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+
+	node := NewNode(ServerConfig{ChainParams: params})
+	assert.True(t, node.chain.Validators().Has(validator.Public().Bytes()))
+	assert.Equal(t, 0, NewNode(ServerConfig{}).chain.Validators().Len())
+}
+
+// badSigner signs with a key other than the one it claims, so the chain rejects
+// its blocks after they are signed.
+type badSigner struct {
+	pubKey *crypto.PublicKey
+	calls  int
+}
+
+func (s *badSigner) PublicKey() *crypto.PublicKey {
+	return s.pubKey
+}
+
+func (s *badSigner) SignHeader(ctx context.Context, header *proto.Header) (*crypto.Signature, error) {
+	s.calls++
+	return crypto.GeneratePrivateKey().Sign(types.HashHeader(header)), nil
+}
+
+func TestCreateBlockRetriesSignedBlock(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		s         = &badSigner{pubKey: validator.Public()}
+		node      = NewNode(ServerConfig{Signer: s})
+		params    = DefaultChainParams()
+		godKey    = crypto.NewPrivateKeyFromSeedString(godSeed)
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	node.chain = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	tx := genesisSpend(t, node.chain, &proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()})
+	require.True(t, node.mempool.Add(tx))
+
+	_, err := node.createBlock()
+	require.NotNil(t, err)
+	signed := node.signed
+	require.NotNil(t, signed)
+
+	// the same block is retried instead of signing a new one
+	_, err = node.createBlock()
+	require.NotNil(t, err)
+	assert.Equal(t, 1, s.calls)
+	assert.Equal(t, signed, node.signed)
+
+	// once the chain moves past it, its transactions go back to the mempool
+	block := randomBlock(t, node.chain)
+	types.SignBlock(validator, block)
+	require.Nil(t, node.chain.AddBlock(block))
+	_, err = node.createBlock()
+	require.NotNil(t, err)
+	assert.Equal(t, 2, s.calls)
+	assert.Equal(t, int32(2), node.signed.Header.Height)
+	assert.Len(t, node.signed.Transactions, 1)
+}
+
+func TestCreateBlockChecksSigner(t *testing.T) {
+	var (
+		node   = NewNode(ServerConfig{PrivateKey: crypto.GeneratePrivateKey()})
+		params = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: crypto.GeneratePrivateKey().Public(), Bond: 100}}
+	node.chain = NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	_, err := node.createBlock()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not a validator")
+	assert.Nil(t, node.signed)
+}
@@ -0,0 +1,72 @@
+package node
+
+// Named rule changes. Each one is enforced for blocks at and above the height
+// it is mapped to in ChainParams.Forks.
+const (
+	// ForkEnforceVersions rejects blocks and transactions with a version
+	// this node doesn't know, instead of only warning about them.
+	ForkEnforceVersions = "enforce-versions"
+	// ForkScripts allows outputs to carry lock scripts, spent by inputs with
+	// a matching unlock script.
+	ForkScripts = "scripts"
+	// ForkEnforceOwnership requires inputs spending an output without lock
+	// script to be signed by the key behind the output address.
+	ForkEnforceOwnership = "enforce-ownership"
+	// ForkMultisig allows M-of-N multisig outputs.
+	ForkMultisig = "multisig"
+	// ForkTimelocks enforces the lock time of transactions and the relative
+	// locks of their inputs.
+	ForkTimelocks = "timelocks"
+	// ForkHTLC allows hash time-locked contract outputs.
+	ForkHTLC = "htlc"
+	// ForkAssets allows issuing assets and outputs denominated in them.
+	ForkAssets = "assets"
+	// ForkDataOutputs allows unspendable outputs carrying data.
+	ForkDataOutputs = "data-outputs"
+	// ForkNames allows registering human readable names for addresses.
+	ForkNames = "names"
+	// ForkChannels allows outputs funding payment channels.
+	ForkChannels = "channels"
+)
+
+type ChainParams struct {
+	// GenesisValidators is the initial validator set. When empty, blocks
+	// signed by any key are accepted.
+	GenesisValidators []*Validator
+	// GovernanceThreshold is the percentage of the current validators that
+	// must sign a validator set change.
+	GovernanceThreshold int
+	// NameRegistrationPeriod is the number of blocks a name stays registered
+	// after registration or renewal.
+	NameRegistrationPeriod int
+	// Forks maps named rule changes to their activation height. Rule
+	// changes missing from the map are never active.
+	Forks map[string]int
+}
+
+func DefaultChainParams() *ChainParams {
+	return &ChainParams{
+		GenesisValidators:      []*Validator{},
+		GovernanceThreshold:    66,
+		NameRegistrationPeriod: 100_000,
+		Forks: map[string]int{
+			ForkEnforceVersions:  0,
+			ForkScripts:          0,
+			ForkEnforceOwnership: 0,
+			ForkMultisig:         0,
+			ForkTimelocks:        0,
+			ForkHTLC:             0,
+			ForkAssets:           0,
+			ForkDataOutputs:      0,
+			ForkNames:            0,
+			ForkChannels:         0,
+		},
+	}
+}
+
+// IsActive reports whether the named rule change applies to the block at the
+// given height.
+func (p *ChainParams) IsActive(fork string, height int) bool {
+	activation, ok := p.Forks[fork]
+	return ok && height >= activation
+}
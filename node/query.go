@@ -0,0 +1,232 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxHeadersPerRequest bounds the size of a GetHeaders response.
+const maxHeadersPerRequest = 2000
+
+// QueryServer serves the Query service of a node, every lookup clients can
+// make. The Node service only carries what nodes exchange with each other.
+type QueryServer struct {
+	proto.UnimplementedQueryServer
+	node *Node
+}
+
+func NewQueryServer(n *Node) *QueryServer {
+	return &QueryServer{node: n}
+}
+
+func (s *QueryServer) GetBlockByHeight(ctx context.Context, req *proto.BlockByHeightRequest) (*proto.Block, error) {
+	if req.Height < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid height %d", req.Height)
+	}
+	block, err := s.node.chain.GetBlockByHeight(int(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return block, nil
+}
+
+func (s *QueryServer) GetBlockByHash(ctx context.Context, req *proto.BlockByHashRequest) (*proto.Block, error) {
+	if len(req.Hash) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid block hash length %d", len(req.Hash))
+	}
+	block, err := s.node.chain.GetBlockByHash(req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return block, nil
+}
+
+func (s *QueryServer) GetHeaders(ctx context.Context, req *proto.HeadersRequest) (*proto.HeadersResponse, error) {
+	if req.From < 0 || req.From > req.To {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid header range %d to %d", req.From, req.To)
+	}
+	if req.To-req.From >= maxHeadersPerRequest {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d headers can be requested at once", maxHeadersPerRequest)
+	}
+	headers, err := s.node.chain.GetHeaders(int(req.From), int(req.To))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &proto.HeadersResponse{Headers: headers}, nil
+}
+
+func (s *QueryServer) GetChainInfo(ctx context.Context, req *proto.ChainInfoRequest) (*proto.ChainInfoResponse, error) {
+	height, tipHash := s.node.chain.Tip()
+	resp := &proto.ChainInfoResponse{
+		Height:      int32(height),
+		TipHash:     tipHash,
+		MempoolSize: int32(s.node.mempool.Len()),
+	}
+	for _, v := range s.node.chain.Validators().List() {
+		resp.Validators = append(resp.Validators, &proto.ValidatorInfo{
+			PublicKey: v.PublicKey.Bytes(),
+			Bond:      v.Bond,
+		})
+	}
+	return resp, nil
+}
+
+func (s *QueryServer) GetUTXO(ctx context.Context, req *proto.UTXORequest) (*proto.UnspentOutput, error) {
+	if len(req.TxHash) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transaction hash length %d", len(req.TxHash))
+	}
+	utxo, err := s.node.chain.GetUTXO(req.TxHash, int(req.OutIndex))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return utxo.Unspent(), nil
+}
+
+// GetPreimage lets the counterparty of an atomic swap pick up the preimage
+// revealed by claiming an HTLC output on this chain.
+func (s *QueryServer) GetPreimage(ctx context.Context, req *proto.PreimageRequest) (*proto.PreimageResponse, error) {
+	if len(req.Hash) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid HTLC hash length %d", len(req.Hash))
+	}
+	preimage, txHash, err := s.node.chain.GetPreimage(req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &proto.PreimageResponse{
+		Preimage: preimage,
+		TxHash:   txHash,
+	}, nil
+}
+
+// GetAnchor tells where a document hash was anchored on chain.
+func (s *QueryServer) GetAnchor(ctx context.Context, req *proto.AnchorRequest) (*proto.AnchorResponse, error) {
+	if len(req.Hash) != types.AnchorLen {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid anchor hash length %d", len(req.Hash))
+	}
+	anchor, err := s.node.chain.GetAnchor(req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &proto.AnchorResponse{
+		BlockHash: anchor.BlockHash,
+		Height:    int32(anchor.Height),
+		TxHash:    anchor.TxHash,
+	}, nil
+}
+
+// ResolveName returns the address a registered name points to.
+func (s *QueryServer) ResolveName(ctx context.Context, req *proto.ResolveNameRequest) (*proto.ResolveNameResponse, error) {
+	if err := types.ValidateName(req.Name); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	record, err := s.node.chain.ResolveName(req.Name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &proto.ResolveNameResponse{
+		Address:   record.Address,
+		ExpiresAt: int32(record.ExpiresAt),
+	}, nil
+}
+
+// GetTransaction returns a transaction and whether it is confirmed, waiting in
+// the mempool, or unknown to this node.
+func (s *QueryServer) GetTransaction(ctx context.Context, req *proto.TxRequest) (*proto.TxResponse, error) {
+	if len(req.Hash) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transaction hash length %d", len(req.Hash))
+	}
+	if confirmed, err := s.node.chain.GetTransaction(req.Hash); err == nil {
+		return &proto.TxResponse{
+			Status:        proto.TxResponse_CONFIRMED,
+			Tx:            confirmed.Tx,
+			BlockHash:     confirmed.BlockHash,
+			Height:        int32(confirmed.Height),
+			Index:         uint32(confirmed.Index),
+			Confirmations: int32(confirmed.Confirmations),
+		}, nil
+	}
+	if tx, ok := s.node.mempool.Get(req.Hash); ok {
+		return &proto.TxResponse{
+			Status: proto.TxResponse_MEMPOOL,
+			Tx:     tx,
+		}, nil
+	}
+	return &proto.TxResponse{Status: proto.TxResponse_UNKNOWN}, nil
+}
+
+// ListUnspent returns the unspent outputs paying to an address.
+func (s *QueryServer) ListUnspent(ctx context.Context, req *proto.AddressRequest) (*proto.ListUnspentResponse, error) {
+	if err := checkAddress(req.Address); err != nil {
+		return nil, err
+	}
+	unspent, err := s.node.chain.ListUnspent(req.Address)
+	if err != nil {
+		return nil, addressQueryError(err)
+	}
+
+	resp := &proto.ListUnspentResponse{}
+	for _, utxo := range unspent {
+		resp.Outputs = append(resp.Outputs, utxo.Unspent())
+	}
+	return resp, nil
+}
+
+// GetBalance returns the native coin balance of an address.
+func (s *QueryServer) GetBalance(ctx context.Context, req *proto.AddressRequest) (*proto.BalanceResponse, error) {
+	if err := checkAddress(req.Address); err != nil {
+		return nil, err
+	}
+	balance, err := s.node.chain.GetBalance(req.Address)
+	if err != nil {
+		return nil, addressQueryError(err)
+	}
+
+	return &proto.BalanceResponse{Balance: balance}, nil
+}
+
+// GetAddressHistory returns the transactions that paid to an address or spent
+// from it.
+func (s *QueryServer) GetAddressHistory(ctx context.Context, req *proto.AddressRequest) (*proto.AddressHistoryResponse, error) {
+	if err := checkAddress(req.Address); err != nil {
+		return nil, err
+	}
+	history, err := s.node.chain.GetAddressHistory(req.Address)
+	if err != nil {
+		return nil, addressQueryError(err)
+	}
+
+	resp := &proto.AddressHistoryResponse{}
+	for _, entry := range history {
+		resp.Entries = append(resp.Entries, &proto.AddressHistoryEntry{
+			TxHash: entry.TxHash,
+			Height: int32(entry.Height),
+		})
+	}
+	return resp, nil
+}
+
+// checkAddress rejects addresses of the wrong length before they are looked up.
+func checkAddress(addr []byte) error {
+	if len(addr) != crypto.AddressLen {
+		return status.Errorf(codes.InvalidArgument, "invalid address length %d", len(addr))
+	}
+	return nil
+}
+
+// addressQueryError maps errors of the address queries to gRPC errors.
+func addressQueryError(err error) error {
+	if errors.Is(err, ErrAddressIndexDisabled) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
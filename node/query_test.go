@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQueryBlocksAndHeaders(t *testing.T) {
+	var (
+		node  = NewNode(ServerConfig{})
+		query = NewQueryServer(node)
+		ctx   = context.Background()
+	)
+	block := addBlockWithTx(t, node.chain)
+	addBlockWithTx(t, node.chain)
+
+	resp, err := query.GetBlockByHeight(ctx, &proto.BlockByHeightRequest{Height: 1})
+	require.Nil(t, err)
+	assert.Equal(t, types.HashBlock(block), types.HashBlock(resp))
+
+	resp, err = query.GetBlockByHash(ctx, &proto.BlockByHashRequest{Hash: types.HashBlock(block)})
+	require.Nil(t, err)
+	assert.Equal(t, block.Header.Height, resp.Header.Height)
+
+	headers, err := query.GetHeaders(ctx, &proto.HeadersRequest{From: 1, To: 2})
+	require.Nil(t, err)
+	require.Equal(t, 2, len(headers.Headers))
+	assert.Equal(t, block.Header, headers.Headers[0])
+
+	info, err := query.GetChainInfo(ctx, &proto.ChainInfoRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, int32(2), info.Height)
+	assert.Equal(t, headers.Headers[1].Height, info.Height)
+	assert.Equal(t, types.HashHeader(headers.Headers[1]), info.TipHash)
+	assert.Equal(t, int32(0), info.MempoolSize)
+
+	_, err = query.GetBlockByHeight(ctx, &proto.BlockByHeightRequest{Height: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = query.GetBlockByHeight(ctx, &proto.BlockByHeightRequest{Height: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetBlockByHash(ctx, &proto.BlockByHashRequest{Hash: util.RandomHash()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = query.GetBlockByHash(ctx, &proto.BlockByHashRequest{Hash: []byte{1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetHeaders(ctx, &proto.HeadersRequest{From: 2, To: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetHeaders(ctx, &proto.HeadersRequest{From: 0, To: maxHeadersPerRequest})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetHeaders(ctx, &proto.HeadersRequest{From: 1, To: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestQueryUTXO(t *testing.T) {
+	var (
+		node  = NewNode(ServerConfig{})
+		query = NewQueryServer(node)
+		ctx   = context.Background()
+		alice = crypto.GeneratePrivateKey()
+	)
+	fundTx, payTx := payAlice(t, node.chain, alice, crypto.GeneratePrivateKey())
+
+	resp, err := query.GetUTXO(ctx, &proto.UTXORequest{TxHash: types.HashTransaction(payTx), OutIndex: 1})
+	require.Nil(t, err)
+	assert.Equal(t, int64(700), resp.Output.Amount)
+	assert.Equal(t, alice.Public().Address().Bytes(), resp.Output.Address)
+	assert.Equal(t, int32(2), resp.Height)
+
+	// spent outputs are not found
+	_, err = query.GetUTXO(ctx, &proto.UTXORequest{TxHash: types.HashTransaction(fundTx)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = query.GetUTXO(ctx, &proto.UTXORequest{TxHash: types.HashTransaction(payTx), OutIndex: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = query.GetUTXO(ctx, &proto.UTXORequest{TxHash: []byte{1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestQueryRejectsMalformedRequests(t *testing.T) {
+	var (
+		node  = NewNode(ServerConfig{AddressIndex: true})
+		query = NewQueryServer(node)
+		ctx   = context.Background()
+		short = []byte{1, 2, 3}
+	)
+
+	_, err := query.GetPreimage(ctx, &proto.PreimageRequest{Hash: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetAnchor(ctx, &proto.AnchorRequest{Hash: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetTransaction(ctx, &proto.TxRequest{Hash: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.ResolveName(ctx, &proto.ResolveNameRequest{Name: "NOT A NAME"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.ListUnspent(ctx, &proto.AddressRequest{Address: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetBalance(ctx, &proto.AddressRequest{Address: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = query.GetAddressHistory(ctx, &proto.AddressRequest{Address: short})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// well formed lookups of unknown data are not found
+	_, err = query.GetPreimage(ctx, &proto.PreimageRequest{Hash: make([]byte, 32)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = query.GetAnchor(ctx, &proto.AnchorRequest{Hash: make([]byte, 32)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	balance, err := query.GetBalance(ctx, &proto.AddressRequest{Address: crypto.GeneratePrivateKey().Public().Address().Bytes()})
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), balance.Balance)
+}
@@ -0,0 +1,188 @@
+package node
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// sigCacheSize is the number of transactions whose signatures are remembered
+// as valid.
+const sigCacheSize = 50_000
+
+// SigCache remembers the hashes of transactions whose input signatures were
+// found valid, and the inputs whose signatures match the keys of the multisig
+// or channel output they spend. The transaction hash covers the signatures and
+// the spent outputs, so a hit means the exact same signatures were verified
+// before. When full, the oldest entry is evicted.
+type SigCache struct {
+	lock    sync.Mutex
+	size    int
+	entries map[string]bool
+	order   []string
+}
+
+func NewSigCache(size int) *SigCache {
+	return &SigCache{
+		size:    size,
+		entries: make(map[string]bool),
+	}
+}
+
+func (c *SigCache) Has(hash []byte) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.entries[hex.EncodeToString(hash)]
+}
+
+func (c *SigCache) Add(hash []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := hex.EncodeToString(hash)
+	if c.entries[key] {
+		return
+	}
+	if len(c.order) >= c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = true
+	c.order = append(c.order, key)
+}
+
+func (c *SigCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.entries)
+}
+
+// checkSignatures verifies the input signatures of the transaction, unless the
+// cache already holds it, and caches it when they are valid.
+func checkSignatures(tx *proto.Transaction, cache *SigCache) error {
+	hash := types.HashTransaction(tx)
+	if cache.Has(hash) {
+		return nil
+	}
+	if err := types.CheckTransactionSignatures(tx); err != nil {
+		return err
+	}
+	cache.Add(hash)
+	return nil
+}
+
+// inputCacheKey identifies an input of a transaction in the SigCache. It is
+// longer than a transaction hash, so the two kinds of entries can't collide.
+func inputCacheKey(txHash []byte, index int) []byte {
+	key := make([]byte, len(txHash), len(txHash)+4)
+	copy(key, txHash)
+	return binary.BigEndian.AppendUint32(key, uint32(index))
+}
+
+// checkLockSignatures verifies the signatures of an input spending a multisig
+// or channel output against the keys of the lock, unless the cache already
+// holds the input, and caches the input when they are valid. Channel refunds
+// depend on the height, so they are never cached.
+func checkLockSignatures(input *proto.TxInput, utxo *UTXO, sigHash, key []byte, height int, cache *SigCache) error {
+	if cache.Has(key) {
+		return nil
+	}
+	switch {
+	case utxo.Multisig != nil:
+		if err := types.VerifyMultisig(utxo.Multisig, input, sigHash); err != nil {
+			return err
+		}
+	case utxo.Channel != nil:
+		if err := types.VerifyChannelSpend(utxo.Channel, input, sigHash, height); err != nil {
+			return err
+		}
+		if len(input.Signatures) == 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	cache.Add(key)
+	return nil
+}
+
+// checkBlockTxSignatures verifies the input signatures of a transaction and the
+// signatures its inputs make for multisig and channel outputs. Inputs spending
+// unknown outputs are skipped, the transaction checks report them.
+func checkBlockTxSignatures(tx *proto.Transaction, utxos UTXOStorer, cache *SigCache, height int) error {
+	if err := checkSignatures(tx, cache); err != nil {
+		return err
+	}
+
+	var hash, sigHash []byte
+	for i, input := range tx.Inputs {
+		// only multisig and channel spends carry these signatures
+		if len(input.Signatures) == 0 {
+			continue
+		}
+		utxo, err := utxos.Get(fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex))
+		if err != nil {
+			continue
+		}
+		if hash == nil {
+			hash, sigHash = types.HashTransaction(tx), types.SigHash(tx)
+		}
+		if err := checkLockSignatures(input, utxo, sigHash, inputCacheKey(hash, i), height, cache); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// verifySignatures checks the signatures of all transactions of a block at
+// the given height on a pool of workers, one per CPU: the input signatures,
+// and the multisig and channel signatures checked against the outputs they
+// spend. Signatures found in the cache are skipped, those that verify are
+// added to it. The error of the first invalid transaction in the list is
+// returned.
+//
+// This only partly covers the signature checks of a block. The standard
+// library has no ed25519 batch verification, so every signature is still
+// verified on its own and the speedup comes from the workers alone. Signatures
+// checked by lock scripts are verified while running the script in
+// validateUnlock, since the result of a script can depend on the height.
+func verifySignatures(txx []*proto.Transaction, utxos UTXOStorer, cache *SigCache, height int) error {
+	var (
+		workers = runtime.NumCPU()
+		errs    = make([]error, len(txx))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	if workers > len(txx) {
+		workers = len(txx)
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				errs[j] = checkBlockTxSignatures(txx[j], utxos, cache, height)
+			}
+		}()
+	}
+	for i := range txx {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("invalid signature in transaction %d: %w", i, err)
+		}
+	}
+	return nil
+}
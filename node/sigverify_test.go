@@ -0,0 +1,107 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/channel"
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/protobuf/proto"
+)
+
+func signedTx(t *testing.T) *proto.Transaction {
+	privKey := crypto.GeneratePrivateKey()
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash: util.RandomHash(),
+			PublicKey:  privKey.Public().Bytes(),
+		}},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(privKey, tx).Bytes()
+	return tx
+}
+
+func TestSigCacheEviction(t *testing.T) {
+	cache := NewSigCache(2)
+	cache.Add([]byte{1})
+	cache.Add([]byte{2})
+	cache.Add([]byte{2})
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Add([]byte{3})
+	assert.Equal(t, 2, cache.Len())
+	assert.False(t, cache.Has([]byte{1}))
+	assert.True(t, cache.Has([]byte{2}))
+	assert.True(t, cache.Has([]byte{3}))
+}
+
+func TestVerifySignatures(t *testing.T) {
+	var (
+		cache = NewSigCache(sigCacheSize)
+		txx   = make([]*proto.Transaction, 64)
+	)
+	for i := range txx {
+		txx[i] = signedTx(t)
+	}
+
+	require.Nil(t, verifySignatures(txx, NewMemoryUTXOStore(), cache, 1))
+	assert.Equal(t, len(txx), cache.Len())
+
+	bad := signedTx(t)
+	bad.Inputs[0].Signature[0] ^= 0xff
+	txx = append(txx, bad)
+	err := verifySignatures(txx, NewMemoryUTXOStore(), cache, 1)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "transaction 64")
+	assert.False(t, cache.Has(types.HashTransaction(bad)))
+
+	require.Nil(t, verifySignatures(nil, NewMemoryUTXOStore(), cache, 1))
+}
+
+func TestAdmissionCachesSignatures(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		tx     = genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()})
+	)
+
+	require.Nil(t, chain.ValidateTransaction(tx))
+	assert.True(t, chain.sigCache.Has(types.HashTransaction(tx)))
+
+	addBlockWithTx(t, chain, tx)
+}
+
+func TestVerifySignaturesOfLocks(t *testing.T) {
+	var (
+		chain    = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		cache    = NewSigCache(sigCacheSize)
+		payerKey = crypto.GeneratePrivateKey()
+		payeeKey = crypto.GeneratePrivateKey()
+		ch       = fundChannel(t, chain, payerKey, payeeKey, 100)
+	)
+
+	payer, err := channel.NewPayer(ch, payerKey)
+	require.Nil(t, err)
+	payee, err := channel.NewPayee(ch, payeeKey)
+	require.Nil(t, err)
+	update, err := payer.Pay(100)
+	require.Nil(t, err)
+	require.Nil(t, payee.Receive(update))
+	closeTx, err := payee.CloseTransaction()
+	require.Nil(t, err)
+
+	require.Nil(t, verifySignatures([]*proto.Transaction{closeTx}, chain.utxoStore, cache, 2))
+	assert.True(t, cache.Has(inputCacheKey(types.HashTransaction(closeTx), 0)))
+
+	bad := pb.Clone(closeTx).(*proto.Transaction)
+	bad.Inputs[0].Signatures[1][0] ^= 0xff
+	require.NotNil(t, verifySignatures([]*proto.Transaction{bad}, chain.utxoStore, cache, 2))
+	assert.False(t, cache.Has(inputCacheKey(types.HashTransaction(bad), 0)))
+
+	addBlockWithTx(t, chain, closeTx)
+}
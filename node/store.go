@@ -12,6 +12,7 @@ import (
 type BlockStorer interface {
 	Put(*proto.Block) error
 	Get(string) (*proto.Block, error)
+	Delete(string) error
 }
 
 type TXStorer interface {
@@ -22,6 +23,7 @@ type TXStorer interface {
 type UTXOStorer interface {
 	Put(*UTXO) error
 	Get(string) (*UTXO, error)
+	Delete(string) error
 }
 
 type MemoryTXStore struct {
@@ -62,6 +64,14 @@ func (s *MemoryUTXOStore) Put(utxo *UTXO) error {
 	return nil
 }
 
+func (s *MemoryUTXOStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.data, key)
+	return nil
+}
+
 func NewMemoryTXStore() *MemoryTXStore {
 	return &MemoryTXStore{
 		txx: make(map[string]*proto.Transaction),
@@ -116,3 +126,10 @@ func (s *MemoryBlockStore) Put(block *proto.Block) error {
 	s.blocks[hash] = block
 	return nil
 }
+
+func (s *MemoryBlockStore) Delete(hash string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.blocks, hash)
+	return nil
+}
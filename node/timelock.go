@@ -0,0 +1,79 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// medianTimeSpan is the number of blocks whose timestamps make up the median
+// time past.
+const medianTimeSpan = 11
+
+// medianTimePast returns the median timestamp, in unix seconds, of the last
+// blocks of the chain. Time based lock times are compared against it instead of
+// the timestamp of a single block, which its validator can choose freely.
+func (c *Chain) medianTimePast() int64 {
+	return c.medianTimestamp() / 1e9
+}
+
+// medianTimestamp returns the median header timestamp, in unix nanoseconds, of
+// the last blocks of the chain.
+func (c *Chain) medianTimestamp() int64 {
+	var timestamps []int64
+	for h := c.headers.Height(); h >= 0 && len(timestamps) < medianTimeSpan; h-- {
+		timestamps = append(timestamps, c.headers.Get(h).Timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return timestamps[len(timestamps)/2]
+}
+
+// IsFinal reports whether the lock time and the relative locks of the
+// transaction allow it into the next block. Inputs spending unknown outputs
+// are not considered locked.
+func (c *Chain) IsFinal(tx *proto.Transaction) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.checkTimelocks(tx, c.headers.Height()+1) == nil
+}
+
+// checkTimelocks checks the lock time and the relative locks of the transaction
+// for a block at the given height on top of the current chain.
+func (c *Chain) checkTimelocks(tx *proto.Transaction, height int) error {
+	if !types.IsFinalTransaction(tx, height, c.medianTimePast()) {
+		return fmt.Errorf("transaction is locked until %d", tx.LockTime)
+	}
+
+	for i, input := range tx.Inputs {
+		if input.RelativeLock == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+		utxo, err := c.utxoStore.Get(key)
+		if err != nil {
+			continue
+		}
+		if age := height - utxo.Height; age < int(input.RelativeLock) {
+			return fmt.Errorf("input %d spends an output of age %d, needs %d", i, age, input.RelativeLock)
+		}
+	}
+	return nil
+}
+
+func hasTimelocks(tx *proto.Transaction) bool {
+	if tx.LockTime != 0 {
+		return true
+	}
+	for _, input := range tx.Inputs {
+		if input.RelativeLock != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbsoluteHeightLock(t *testing.T) {
+	var (
+		chain   = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey  = crypto.NewPrivateKeyFromSeedString(godSeed)
+		address = godKey.Public().Address().Bytes()
+	)
+
+	tx := genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: address})
+	tx.LockTime = 3
+	tx.Inputs[0].Signature = nil
+	tx.Inputs[0].Signature = types.SignTransaction(godKey, tx).Bytes()
+
+	// the next block is at height 1
+	assert.False(t, chain.IsFinal(tx))
+	require.NotNil(t, chain.ValidateTransaction(tx))
+
+	addBlockWithTx(t, chain)
+	addBlockWithTx(t, chain)
+
+	assert.True(t, chain.IsFinal(tx))
+	addBlockWithTx(t, chain, tx)
+}
+
+func TestAbsoluteTimeLock(t *testing.T) {
+	var (
+		chain   = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey  = crypto.NewPrivateKeyFromSeedString(godSeed)
+		address = godKey.Public().Address().Bytes()
+		now     = time.Now()
+	)
+
+	tx := genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: address})
+	tx.LockTime = now.Unix()
+	tx.Inputs[0].Signature = nil
+	tx.Inputs[0].Signature = types.SignTransaction(godKey, tx).Bytes()
+
+	addBlockAt := func(ts time.Time) {
+		block := randomBlock(t, chain)
+		block.Header.Timestamp = ts.UnixNano()
+		types.SignBlock(godKey, block)
+		require.Nil(t, chain.AddBlock(block))
+	}
+
+	// every block has to be later than the median of the ones before it
+	for i := 0; i < medianTimeSpan; i++ {
+		addBlockAt(now.Add(-time.Hour + time.Duration(i)*time.Second))
+	}
+	assert.False(t, chain.IsFinal(tx))
+
+	// the median of the last blocks has to pass the lock time, not a
+	// single block
+	for i := 0; i < medianTimeSpan/2; i++ {
+		addBlockAt(now.Add(time.Hour + time.Duration(i)*time.Second))
+	}
+	assert.False(t, chain.IsFinal(tx))
+
+	addBlockAt(now.Add(time.Hour + time.Minute))
+	assert.True(t, chain.IsFinal(tx))
+	require.Nil(t, chain.ValidateTransaction(tx))
+}
+
+func TestRelativeLock(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		alice  = crypto.GeneratePrivateKey()
+	)
+
+	fundTx := genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()})
+	addBlockWithTx(t, chain, fundTx)
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash:   types.HashTransaction(fundTx),
+			PrevOutIndex: 0,
+			PublicKey:    alice.Public().Bytes(),
+			RelativeLock: 3,
+		}},
+		Outputs: []*proto.TxOutput{{Amount: 1000, Address: godKey.Public().Address().Bytes()}},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(alice, tx).Bytes()
+
+	// the output was created at height 1
+	assert.False(t, chain.IsFinal(tx))
+	addBlockWithTx(t, chain)
+	assert.False(t, chain.IsFinal(tx))
+	require.NotNil(t, chain.ValidateTransaction(tx))
+	addBlockWithTx(t, chain)
+	assert.True(t, chain.IsFinal(tx))
+	addBlockWithTx(t, chain, tx)
+}
+
+func TestCreateBlockHoldsLockedTransactions(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		node      = NewNode(ServerConfig{PrivateKey: validator})
+		godKey    = crypto.NewPrivateKeyFromSeedString(godSeed)
+		address   = godKey.Public().Address().Bytes()
+	)
+
+	locked := genesisSpend(t, node.chain, &proto.TxOutput{Amount: 1000, Address: address})
+	locked.LockTime = 2
+	locked.Inputs[0].Signature = nil
+	locked.Inputs[0].Signature = types.SignTransaction(godKey, locked).Bytes()
+	require.True(t, node.mempool.Add(locked))
+
+	block, err := node.createBlock()
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(block.Transactions))
+	assert.Equal(t, 1, node.mempool.Len())
+
+	block, err = node.createBlock()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(block.Transactions))
+	assert.Equal(t, locked, block.Transactions[0])
+	assert.Equal(t, 0, node.mempool.Len())
+	assert.Equal(t, 2, node.chain.Height())
+}
+
+func TestBlockTimestamp(t *testing.T) {
+	var (
+		chain = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		now   = time.Now()
+	)
+
+	addBlockAt := func(ts time.Time) error {
+		block := randomBlock(t, chain)
+		block.Header.Timestamp = ts.UnixNano()
+		types.SignBlock(crypto.GeneratePrivateKey(), block)
+		return chain.AddBlock(block)
+	}
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, addBlockAt(now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	// not after the median of the last blocks
+	assert.NotNil(t, addBlockAt(now.Add(time.Minute)))
+	assert.NotNil(t, addBlockAt(now.Add(-time.Hour)))
+	// too far ahead of the local clock
+	assert.NotNil(t, addBlockAt(time.Now().Add(maxBlockTimeDrift+time.Minute)))
+
+	// older than the last block is fine, as long as it is after the median
+	require.Nil(t, addBlockAt(now.Add(time.Minute+time.Second)))
+	require.Nil(t, addBlockAt(time.Now().Add(maxBlockTimeDrift-time.Minute)))
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// TxLocation is the place a transaction was included in the chain.
+type TxLocation struct {
+	BlockHash []byte
+	Height    int
+	// Index is the position of the transaction in the block.
+	Index int
+}
+
+// ConfirmedTx is a transaction included in the chain, with the number of
+// blocks confirming it, counting its own block.
+type ConfirmedTx struct {
+	Tx *proto.Transaction
+	TxLocation
+	Confirmations int
+}
+
+func (c *Chain) indexTransaction(undo *blockUndo, tx *proto.Transaction, blockHash []byte, height, index int) {
+	key := hex.EncodeToString(types.HashTransaction(tx))
+	prev, existed := c.txIndex[key]
+	c.txIndex[key] = &TxLocation{
+		BlockHash: blockHash,
+		Height:    height,
+		Index:     index,
+	}
+	undo.add(func() error {
+		if existed {
+			c.txIndex[key] = prev
+		} else {
+			delete(c.txIndex, key)
+		}
+		return nil
+	})
+}
+
+// GetTransaction returns the transaction with the given hash if it is included
+// in the chain, along with its location and confirmations.
+func (c *Chain) GetTransaction(hash []byte) (*ConfirmedTx, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	loc, ok := c.txIndex[hex.EncodeToString(hash)]
+	if !ok {
+		return nil, fmt.Errorf("transaction %x is not in the chain", hash)
+	}
+	b, err := c.getBlockByHeight(loc.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfirmedTx{
+		Tx:            b.Transactions[loc.Index],
+		TxLocation:    *loc,
+		Confirmations: c.headers.Height() - loc.Height + 1,
+	}, nil
+}
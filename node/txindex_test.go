@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionIndex(t *testing.T) {
+	var (
+		chain = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		alice = crypto.GeneratePrivateKey()
+	)
+
+	tx := genesisSpend(t, chain, &proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()})
+	txHash := types.HashTransaction(tx)
+	_, err := chain.GetTransaction(txHash)
+	require.NotNil(t, err)
+
+	block := addBlockWithTx(t, chain, tx)
+	addBlockWithTx(t, chain)
+
+	confirmed, err := chain.GetTransaction(txHash)
+	require.Nil(t, err)
+	assert.Equal(t, tx, confirmed.Tx)
+	assert.Equal(t, types.HashBlock(block), confirmed.BlockHash)
+	assert.Equal(t, 1, confirmed.Height)
+	assert.Equal(t, len(block.Transactions)-1, confirmed.Index)
+	assert.Equal(t, 2, confirmed.Confirmations)
+
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	confirmed, err = chain.GetTransaction(txHash)
+	require.Nil(t, err)
+	assert.Equal(t, 1, confirmed.Confirmations)
+
+	_, err = chain.DisconnectTip()
+	require.Nil(t, err)
+	_, err = chain.GetTransaction(txHash)
+	require.NotNil(t, err)
+}
+
+func TestGetTransactionRPC(t *testing.T) {
+	var (
+		node  = NewNode(ServerConfig{})
+		alice = crypto.GeneratePrivateKey()
+		ctx   = context.Background()
+	)
+
+	resp, err := NewQueryServer(node).GetTransaction(ctx, &proto.TxRequest{Hash: util.RandomHash()})
+	require.Nil(t, err)
+	assert.Equal(t, proto.TxResponse_UNKNOWN, resp.Status)
+
+	tx := genesisSpend(t, node.chain, &proto.TxOutput{Amount: 1000, Address: alice.Public().Address().Bytes()})
+	req := &proto.TxRequest{Hash: types.HashTransaction(tx)}
+	require.True(t, node.mempool.Add(tx))
+
+	resp, err = NewQueryServer(node).GetTransaction(ctx, req)
+	require.Nil(t, err)
+	assert.Equal(t, proto.TxResponse_MEMPOOL, resp.Status)
+	assert.Equal(t, req.Hash, types.HashTransaction(resp.Tx))
+
+	node.mempool.Clear()
+	addBlockWithTx(t, node.chain, tx)
+
+	resp, err = NewQueryServer(node).GetTransaction(ctx, req)
+	require.Nil(t, err)
+	assert.Equal(t, proto.TxResponse_CONFIRMED, resp.Status)
+	assert.Equal(t, int32(1), resp.Height)
+	assert.Equal(t, int32(1), resp.Confirmations)
+}
@@ -0,0 +1,57 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// blockUndo holds the operations reverting the changes a block made to the
+// chain state, in the order the changes were made.
+type blockUndo struct {
+	ops []func() error
+}
+
+func (u *blockUndo) add(op func() error) {
+	u.ops = append(u.ops, op)
+}
+
+// revert runs the operations in reverse order.
+func (u *blockUndo) revert() error {
+	for i := len(u.ops) - 1; i >= 0; i-- {
+		if err := u.ops[i](); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisconnectTip removes the block at the tip of the chain and reverts
+// everything it changed, returning the removed block. The genesis block can't
+// be disconnected.
+func (c *Chain) DisconnectTip() (*proto.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	height := c.headers.Height()
+	if height == 0 {
+		return nil, fmt.Errorf("can't disconnect the genesis block")
+	}
+	b, err := c.getBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.undoLog[height].revert(); err != nil {
+		return nil, err
+	}
+	c.undoLog = c.undoLog[:height]
+	c.headers.RemoveTip()
+
+	if err := c.blockStore.Delete(hex.EncodeToString(types.HashBlock(b))); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisconnectTip(t *testing.T) {
+	var (
+		chain  = NewChain(NewMemoryBlockStore(), NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		doc    = sha256.Sum256([]byte("doc"))
+	)
+
+	_, err := chain.DisconnectTip()
+	require.NotNil(t, err)
+
+	tx := genesisSpend(t, chain,
+		&proto.TxOutput{Amount: 1000, Address: godKey.Public().Address().Bytes()},
+		types.NewDataOutput(doc[:]),
+	)
+	block := addBlockWithTx(t, chain, tx)
+
+	disconnected, err := chain.DisconnectTip()
+	require.Nil(t, err)
+	assert.Equal(t, block, disconnected)
+	assert.Equal(t, 0, chain.Height())
+	assert.False(t, chain.HasBlock(types.HashBlock(block)))
+
+	_, err = chain.GetAnchor(doc[:])
+	assert.NotNil(t, err)
+	_, err = chain.utxoStore.Get(fmt.Sprintf("%s_%d", hex.EncodeToString(types.HashTransaction(tx)), 0))
+	assert.NotNil(t, err)
+
+	// the genesis output is spendable again
+	require.Nil(t, chain.ValidateTransaction(tx))
+	require.Nil(t, chain.AddBlock(block))
+	assert.Equal(t, 1, chain.Height())
+}
+
+func TestDisconnectRevertsValidatorSet(t *testing.T) {
+	var (
+		validator = crypto.GeneratePrivateKey()
+		params    = DefaultChainParams()
+	)
+	params.GenesisValidators = []*Validator{{PublicKey: validator.Public(), Bond: 100}}
+	chain := NewChainWithParams(NewMemoryBlockStore(), NewMemoryTXStore(), params)
+
+	other := crypto.GeneratePrivateKey()
+	change := &proto.ValidatorSetChange{
+		ActivationHeight: 2,
+		Updates:          []*proto.ValidatorUpdate{{PublicKey: other.Public().Bytes(), Bond: 50}},
+	}
+	govTx := types.NewGovernanceTransaction(change, types.SignValidatorSetChange(validator, change))
+
+	block := randomBlock(t, chain)
+	block.Transactions = append(block.Transactions, govTx)
+	types.SignBlock(validator, block)
+	require.Nil(t, chain.AddBlock(block))
+	assert.Equal(t, 2, chain.Validators().Len())
+
+	_, err := chain.DisconnectTip()
+	require.Nil(t, err)
+	assert.Equal(t, 1, chain.Validators().Len())
+	assert.Equal(t, 1, len(chain.validatorHistory))
+	assert.Empty(t, chain.pendingChanges)
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/mhg14/ChlockBane/crypto"
+)
+
+type Validator struct {
+	PublicKey *crypto.PublicKey
+	Bond      int64
+}
+
+// ValidatorSet holds the validators allowed to sign blocks, keyed by the hex
+// encoding of their public key. An empty set means any key may sign blocks.
+type ValidatorSet struct {
+	validators map[string]*Validator
+}
+
+func NewValidatorSet(validators ...*Validator) *ValidatorSet {
+	set := &ValidatorSet{
+		validators: make(map[string]*Validator),
+	}
+	for _, v := range validators {
+		set.Add(v)
+	}
+	return set
+}
+
+func (set *ValidatorSet) Add(v *Validator) {
+	set.validators[hex.EncodeToString(v.PublicKey.Bytes())] = v
+}
+
+func (set *ValidatorSet) Remove(pubKey []byte) {
+	delete(set.validators, hex.EncodeToString(pubKey))
+}
+
+func (set *ValidatorSet) Get(pubKey []byte) (*Validator, bool) {
+	v, ok := set.validators[hex.EncodeToString(pubKey)]
+	return v, ok
+}
+
+func (set *ValidatorSet) Has(pubKey []byte) bool {
+	_, ok := set.Get(pubKey)
+	return ok
+}
+
+func (set *ValidatorSet) Len() int {
+	return len(set.validators)
+}
+
+// List returns the validators sorted by public key.
+func (set *ValidatorSet) List() []*Validator {
+	keys := make([]string, 0, len(set.validators))
+	for k := range set.validators {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	list := make([]*Validator, len(keys))
+	for i, k := range keys {
+		list[i] = set.validators[k]
+	}
+	return list
+}
+
+func (set *ValidatorSet) Copy() *ValidatorSet {
+	cp := NewValidatorSet()
+	for k, v := range set.validators {
+		cp.validators[k] = &Validator{
+			PublicKey: v.PublicKey,
+			Bond:      v.Bond,
+		}
+	}
+	return cp
+}
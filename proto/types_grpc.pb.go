@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion7
 
 const (
 	Node_HandleTransaction_FullMethodName = "/Node/HandleTransaction"
+	Node_HandleBlock_FullMethodName       = "/Node/HandleBlock"
 	Node_Handshake_FullMethodName         = "/Node/Handshake"
 )
 
@@ -28,6 +29,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type NodeClient interface {
 	HandleTransaction(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Ack, error)
+	HandleBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Ack, error)
 	Handshake(ctx context.Context, in *Version, opts ...grpc.CallOption) (*Version, error)
 }
 
@@ -48,6 +50,15 @@ func (c *nodeClient) HandleTransaction(ctx context.Context, in *Transaction, opt
 	return out, nil
 }
 
+func (c *nodeClient) HandleBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Node_HandleBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *nodeClient) Handshake(ctx context.Context, in *Version, opts ...grpc.CallOption) (*Version, error) {
 	out := new(Version)
 	err := c.cc.Invoke(ctx, Node_Handshake_FullMethodName, in, out, opts...)
@@ -62,6 +73,7 @@ func (c *nodeClient) Handshake(ctx context.Context, in *Version, opts ...grpc.Ca
 // for forward compatibility
 type NodeServer interface {
 	HandleTransaction(context.Context, *Transaction) (*Ack, error)
+	HandleBlock(context.Context, *Block) (*Ack, error)
 	Handshake(context.Context, *Version) (*Version, error)
 	mustEmbedUnimplementedNodeServer()
 }
@@ -73,6 +85,9 @@ type UnimplementedNodeServer struct {
 func (UnimplementedNodeServer) HandleTransaction(context.Context, *Transaction) (*Ack, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HandleTransaction not implemented")
 }
+func (UnimplementedNodeServer) HandleBlock(context.Context, *Block) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleBlock not implemented")
+}
 func (UnimplementedNodeServer) Handshake(context.Context, *Version) (*Version, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
 }
@@ -107,6 +122,24 @@ func _Node_HandleTransaction_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Node_HandleBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Block)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).HandleBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_HandleBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).HandleBlock(ctx, req.(*Block))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Node_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Version)
 	if err := dec(in); err != nil {
@@ -136,6 +169,10 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "HandleTransaction",
 			Handler:    _Node_HandleTransaction_Handler,
 		},
+		{
+			MethodName: "HandleBlock",
+			Handler:    _Node_HandleBlock_Handler,
+		},
 		{
 			MethodName: "Handshake",
 			Handler:    _Node_Handshake_Handler,
@@ -144,3 +181,635 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/types.proto",
 }
+
+const (
+	Query_GetBlockByHeight_FullMethodName  = "/Query/GetBlockByHeight"
+	Query_GetBlockByHash_FullMethodName    = "/Query/GetBlockByHash"
+	Query_GetHeaders_FullMethodName        = "/Query/GetHeaders"
+	Query_GetChainInfo_FullMethodName      = "/Query/GetChainInfo"
+	Query_GetUTXO_FullMethodName           = "/Query/GetUTXO"
+	Query_GetTransaction_FullMethodName    = "/Query/GetTransaction"
+	Query_GetPreimage_FullMethodName       = "/Query/GetPreimage"
+	Query_GetAnchor_FullMethodName         = "/Query/GetAnchor"
+	Query_ResolveName_FullMethodName       = "/Query/ResolveName"
+	Query_ListUnspent_FullMethodName       = "/Query/ListUnspent"
+	Query_GetBalance_FullMethodName        = "/Query/GetBalance"
+	Query_GetAddressHistory_FullMethodName = "/Query/GetAddressHistory"
+)
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QueryClient interface {
+	GetBlockByHeight(ctx context.Context, in *BlockByHeightRequest, opts ...grpc.CallOption) (*Block, error)
+	GetBlockByHash(ctx context.Context, in *BlockByHashRequest, opts ...grpc.CallOption) (*Block, error)
+	// At most 2000 headers are returned per request.
+	GetHeaders(ctx context.Context, in *HeadersRequest, opts ...grpc.CallOption) (*HeadersResponse, error)
+	GetChainInfo(ctx context.Context, in *ChainInfoRequest, opts ...grpc.CallOption) (*ChainInfoResponse, error)
+	// Spent outputs are reported as NotFound.
+	GetUTXO(ctx context.Context, in *UTXORequest, opts ...grpc.CallOption) (*UnspentOutput, error)
+	GetTransaction(ctx context.Context, in *TxRequest, opts ...grpc.CallOption) (*TxResponse, error)
+	GetPreimage(ctx context.Context, in *PreimageRequest, opts ...grpc.CallOption) (*PreimageResponse, error)
+	GetAnchor(ctx context.Context, in *AnchorRequest, opts ...grpc.CallOption) (*AnchorResponse, error)
+	ResolveName(ctx context.Context, in *ResolveNameRequest, opts ...grpc.CallOption) (*ResolveNameResponse, error)
+	// The address queries fail with FailedPrecondition on nodes without
+	// the address index.
+	ListUnspent(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*ListUnspentResponse, error)
+	GetBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	GetAddressHistory(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*AddressHistoryResponse, error)
+}
+
+type queryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueryClient(cc grpc.ClientConnInterface) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) GetBlockByHeight(ctx context.Context, in *BlockByHeightRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Query_GetBlockByHeight_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetBlockByHash(ctx context.Context, in *BlockByHashRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Query_GetBlockByHash_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetHeaders(ctx context.Context, in *HeadersRequest, opts ...grpc.CallOption) (*HeadersResponse, error) {
+	out := new(HeadersResponse)
+	err := c.cc.Invoke(ctx, Query_GetHeaders_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetChainInfo(ctx context.Context, in *ChainInfoRequest, opts ...grpc.CallOption) (*ChainInfoResponse, error) {
+	out := new(ChainInfoResponse)
+	err := c.cc.Invoke(ctx, Query_GetChainInfo_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetUTXO(ctx context.Context, in *UTXORequest, opts ...grpc.CallOption) (*UnspentOutput, error) {
+	out := new(UnspentOutput)
+	err := c.cc.Invoke(ctx, Query_GetUTXO_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetTransaction(ctx context.Context, in *TxRequest, opts ...grpc.CallOption) (*TxResponse, error) {
+	out := new(TxResponse)
+	err := c.cc.Invoke(ctx, Query_GetTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetPreimage(ctx context.Context, in *PreimageRequest, opts ...grpc.CallOption) (*PreimageResponse, error) {
+	out := new(PreimageResponse)
+	err := c.cc.Invoke(ctx, Query_GetPreimage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetAnchor(ctx context.Context, in *AnchorRequest, opts ...grpc.CallOption) (*AnchorResponse, error) {
+	out := new(AnchorResponse)
+	err := c.cc.Invoke(ctx, Query_GetAnchor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) ResolveName(ctx context.Context, in *ResolveNameRequest, opts ...grpc.CallOption) (*ResolveNameResponse, error) {
+	out := new(ResolveNameResponse)
+	err := c.cc.Invoke(ctx, Query_ResolveName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) ListUnspent(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*ListUnspentResponse, error) {
+	out := new(ListUnspentResponse)
+	err := c.cc.Invoke(ctx, Query_ListUnspent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error) {
+	out := new(BalanceResponse)
+	err := c.cc.Invoke(ctx, Query_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetAddressHistory(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*AddressHistoryResponse, error) {
+	out := new(AddressHistoryResponse)
+	err := c.cc.Invoke(ctx, Query_GetAddressHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServer is the server API for Query service.
+// All implementations must embed UnimplementedQueryServer
+// for forward compatibility
+type QueryServer interface {
+	GetBlockByHeight(context.Context, *BlockByHeightRequest) (*Block, error)
+	GetBlockByHash(context.Context, *BlockByHashRequest) (*Block, error)
+	// At most 2000 headers are returned per request.
+	GetHeaders(context.Context, *HeadersRequest) (*HeadersResponse, error)
+	GetChainInfo(context.Context, *ChainInfoRequest) (*ChainInfoResponse, error)
+	// Spent outputs are reported as NotFound.
+	GetUTXO(context.Context, *UTXORequest) (*UnspentOutput, error)
+	GetTransaction(context.Context, *TxRequest) (*TxResponse, error)
+	GetPreimage(context.Context, *PreimageRequest) (*PreimageResponse, error)
+	GetAnchor(context.Context, *AnchorRequest) (*AnchorResponse, error)
+	ResolveName(context.Context, *ResolveNameRequest) (*ResolveNameResponse, error)
+	// The address queries fail with FailedPrecondition on nodes without
+	// the address index.
+	ListUnspent(context.Context, *AddressRequest) (*ListUnspentResponse, error)
+	GetBalance(context.Context, *AddressRequest) (*BalanceResponse, error)
+	GetAddressHistory(context.Context, *AddressRequest) (*AddressHistoryResponse, error)
+	mustEmbedUnimplementedQueryServer()
+}
+
+// UnimplementedQueryServer must be embedded to have forward compatible implementations.
+type UnimplementedQueryServer struct {
+}
+
+func (UnimplementedQueryServer) GetBlockByHeight(context.Context, *BlockByHeightRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHeight not implemented")
+}
+func (UnimplementedQueryServer) GetBlockByHash(context.Context, *BlockByHashRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHash not implemented")
+}
+func (UnimplementedQueryServer) GetHeaders(context.Context, *HeadersRequest) (*HeadersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHeaders not implemented")
+}
+func (UnimplementedQueryServer) GetChainInfo(context.Context, *ChainInfoRequest) (*ChainInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChainInfo not implemented")
+}
+func (UnimplementedQueryServer) GetUTXO(context.Context, *UTXORequest) (*UnspentOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUTXO not implemented")
+}
+func (UnimplementedQueryServer) GetTransaction(context.Context, *TxRequest) (*TxResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedQueryServer) GetPreimage(context.Context, *PreimageRequest) (*PreimageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPreimage not implemented")
+}
+func (UnimplementedQueryServer) GetAnchor(context.Context, *AnchorRequest) (*AnchorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnchor not implemented")
+}
+func (UnimplementedQueryServer) ResolveName(context.Context, *ResolveNameRequest) (*ResolveNameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveName not implemented")
+}
+func (UnimplementedQueryServer) ListUnspent(context.Context, *AddressRequest) (*ListUnspentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUnspent not implemented")
+}
+func (UnimplementedQueryServer) GetBalance(context.Context, *AddressRequest) (*BalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedQueryServer) GetAddressHistory(context.Context, *AddressRequest) (*AddressHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddressHistory not implemented")
+}
+func (UnimplementedQueryServer) mustEmbedUnimplementedQueryServer() {}
+
+// UnsafeQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QueryServer will
+// result in compilation errors.
+type UnsafeQueryServer interface {
+	mustEmbedUnimplementedQueryServer()
+}
+
+func RegisterQueryServer(s grpc.ServiceRegistrar, srv QueryServer) {
+	s.RegisterService(&Query_ServiceDesc, srv)
+}
+
+func _Query_GetBlockByHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockByHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBlockByHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetBlockByHeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBlockByHeight(ctx, req.(*BlockByHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetBlockByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockByHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBlockByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetBlockByHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBlockByHash(ctx, req.(*BlockByHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetHeaders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeadersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetHeaders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetHeaders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetHeaders(ctx, req.(*HeadersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetChainInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChainInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetChainInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetChainInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetChainInfo(ctx, req.(*ChainInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetUTXO_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UTXORequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetUTXO(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetUTXO_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetUTXO(ctx, req.(*UTXORequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetTransaction(ctx, req.(*TxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetPreimage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreimageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetPreimage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetPreimage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetPreimage(ctx, req.(*PreimageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetAnchor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnchorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetAnchor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetAnchor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetAnchor(ctx, req.(*AnchorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_ResolveName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ResolveName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_ResolveName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ResolveName(ctx, req.(*ResolveNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_ListUnspent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ListUnspent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_ListUnspent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ListUnspent(ctx, req.(*AddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBalance(ctx, req.(*AddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetAddressHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetAddressHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetAddressHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetAddressHistory(ctx, req.(*AddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Query_ServiceDesc is the grpc.ServiceDesc for Query service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Query_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlockByHeight",
+			Handler:    _Query_GetBlockByHeight_Handler,
+		},
+		{
+			MethodName: "GetBlockByHash",
+			Handler:    _Query_GetBlockByHash_Handler,
+		},
+		{
+			MethodName: "GetHeaders",
+			Handler:    _Query_GetHeaders_Handler,
+		},
+		{
+			MethodName: "GetChainInfo",
+			Handler:    _Query_GetChainInfo_Handler,
+		},
+		{
+			MethodName: "GetUTXO",
+			Handler:    _Query_GetUTXO_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Query_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetPreimage",
+			Handler:    _Query_GetPreimage_Handler,
+		},
+		{
+			MethodName: "GetAnchor",
+			Handler:    _Query_GetAnchor_Handler,
+		},
+		{
+			MethodName: "ResolveName",
+			Handler:    _Query_ResolveName_Handler,
+		},
+		{
+			MethodName: "ListUnspent",
+			Handler:    _Query_ListUnspent_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Query_GetBalance_Handler,
+		},
+		{
+			MethodName: "GetAddressHistory",
+			Handler:    _Query_GetAddressHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/types.proto",
+}
+
+const (
+	Signer_GetPublicKey_FullMethodName = "/Signer/GetPublicKey"
+	Signer_SignHeader_FullMethodName   = "/Signer/SignHeader"
+)
+
+// SignerClient is the client API for Signer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SignerClient interface {
+	GetPublicKey(ctx context.Context, in *PublicKeyRequest, opts ...grpc.CallOption) (*PublicKeyResponse, error)
+	SignHeader(ctx context.Context, in *SignHeaderRequest, opts ...grpc.CallOption) (*SignHeaderResponse, error)
+}
+
+type signerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSignerClient(cc grpc.ClientConnInterface) SignerClient {
+	return &signerClient{cc}
+}
+
+func (c *signerClient) GetPublicKey(ctx context.Context, in *PublicKeyRequest, opts ...grpc.CallOption) (*PublicKeyResponse, error) {
+	out := new(PublicKeyResponse)
+	err := c.cc.Invoke(ctx, Signer_GetPublicKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerClient) SignHeader(ctx context.Context, in *SignHeaderRequest, opts ...grpc.CallOption) (*SignHeaderResponse, error) {
+	out := new(SignHeaderResponse)
+	err := c.cc.Invoke(ctx, Signer_SignHeader_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SignerServer is the server API for Signer service.
+// All implementations must embed UnimplementedSignerServer
+// for forward compatibility
+type SignerServer interface {
+	GetPublicKey(context.Context, *PublicKeyRequest) (*PublicKeyResponse, error)
+	SignHeader(context.Context, *SignHeaderRequest) (*SignHeaderResponse, error)
+	mustEmbedUnimplementedSignerServer()
+}
+
+// UnimplementedSignerServer must be embedded to have forward compatible implementations.
+type UnimplementedSignerServer struct {
+}
+
+func (UnimplementedSignerServer) GetPublicKey(context.Context, *PublicKeyRequest) (*PublicKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPublicKey not implemented")
+}
+func (UnimplementedSignerServer) SignHeader(context.Context, *SignHeaderRequest) (*SignHeaderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignHeader not implemented")
+}
+func (UnimplementedSignerServer) mustEmbedUnimplementedSignerServer() {}
+
+// UnsafeSignerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SignerServer will
+// result in compilation errors.
+type UnsafeSignerServer interface {
+	mustEmbedUnimplementedSignerServer()
+}
+
+func RegisterSignerServer(s grpc.ServiceRegistrar, srv SignerServer) {
+	s.RegisterService(&Signer_ServiceDesc, srv)
+}
+
+func _Signer_GetPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServer).GetPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Signer_GetPublicKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServer).GetPublicKey(ctx, req.(*PublicKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Signer_SignHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignHeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServer).SignHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Signer_SignHeader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServer).SignHeader(ctx, req.(*SignHeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Signer_ServiceDesc is the grpc.ServiceDesc for Signer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Signer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Signer",
+	HandlerType: (*SignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPublicKey",
+			Handler:    _Signer_GetPublicKey_Handler,
+		},
+		{
+			MethodName: "SignHeader",
+			Handler:    _Signer_SignHeader_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/types.proto",
+}
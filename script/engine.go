@@ -0,0 +1,305 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+)
+
+var ErrScriptFailed = errors.New("script evaluated to false")
+
+// Context is what the script can see of the transaction spending the output.
+type Context struct {
+	// SigHash is the message every signature checked by the script must sign.
+	SigHash []byte
+	// Height is the height of the block the spending transaction is part of.
+	Height int64
+}
+
+type engine struct {
+	ctx   *Context
+	stack [][]byte
+	// conds tracks the branches of the surrounding OpIf blocks, true for
+	// those being executed.
+	conds []bool
+	ops   int
+}
+
+// Execute runs the unlocking script followed by the locking script and returns
+// an error unless the unlocking script satisfies the lock.
+func Execute(unlockScript, lockScript []byte, ctx *Context) error {
+	if !IsPushOnly(unlockScript) {
+		return fmt.Errorf("unlocking script must only push data")
+	}
+
+	e := &engine{ctx: ctx}
+	for _, s := range [][]byte{unlockScript, lockScript} {
+		instructions, err := parse(s)
+		if err != nil {
+			return err
+		}
+		for _, in := range instructions {
+			if err := e.step(in); err != nil {
+				return fmt.Errorf("%s: %w", in.op, err)
+			}
+		}
+		if len(e.conds) != 0 {
+			return fmt.Errorf("unbalanced conditional")
+		}
+	}
+
+	if len(e.stack) == 0 || !asBool(e.stack[len(e.stack)-1]) {
+		return ErrScriptFailed
+	}
+	return nil
+}
+
+func (e *engine) executing() bool {
+	for _, c := range e.conds {
+		if !c {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *engine) push(b []byte) error {
+	if len(b) > MaxElementSize {
+		return fmt.Errorf("element size %d exceeds %d", len(b), MaxElementSize)
+	}
+	if len(e.stack) >= MaxStackSize {
+		return fmt.Errorf("stack size exceeds %d", MaxStackSize)
+	}
+	e.stack = append(e.stack, b)
+	return nil
+}
+
+func (e *engine) pop() ([]byte, error) {
+	if len(e.stack) == 0 {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	b := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	return b, nil
+}
+
+func (e *engine) peek() ([]byte, error) {
+	if len(e.stack) == 0 {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	return e.stack[len(e.stack)-1], nil
+}
+
+func (e *engine) popNum() (int64, error) {
+	b, err := e.pop()
+	if err != nil {
+		return 0, err
+	}
+	return decodeNum(b)
+}
+
+func (e *engine) pushBool(v bool) error {
+	if v {
+		return e.push([]byte{1})
+	}
+	return e.push([]byte{})
+}
+
+func (e *engine) step(in instruction) error {
+	if !in.isPush() {
+		e.ops++
+		if e.ops > MaxOps {
+			return fmt.Errorf("operation count exceeds %d", MaxOps)
+		}
+	}
+
+	switch in.op {
+	case OpIf, OpNotIf:
+		cond := false
+		if e.executing() {
+			b, err := e.pop()
+			if err != nil {
+				return err
+			}
+			cond = asBool(b) == (in.op == OpIf)
+		}
+		e.conds = append(e.conds, cond)
+		return nil
+	case OpElse:
+		if len(e.conds) == 0 {
+			return fmt.Errorf("else without if")
+		}
+		e.conds[len(e.conds)-1] = !e.conds[len(e.conds)-1]
+		return nil
+	case OpEndIf:
+		if len(e.conds) == 0 {
+			return fmt.Errorf("endif without if")
+		}
+		e.conds = e.conds[:len(e.conds)-1]
+		return nil
+	}
+
+	if !e.executing() {
+		return nil
+	}
+
+	if in.isPush() {
+		switch {
+		case in.data != nil || in.op == Op0:
+			return e.push(in.data)
+		case in.op == Op1Negate:
+			return e.push(encodeNum(-1))
+		default:
+			return e.push(encodeNum(int64(in.op-Op1) + 1))
+		}
+	}
+
+	switch in.op {
+	case OpNop:
+		return nil
+	case OpVerify:
+		return e.verify()
+	case OpReturn:
+		return fmt.Errorf("output is unspendable")
+	case OpDrop:
+		_, err := e.pop()
+		return err
+	case OpDup:
+		b, err := e.peek()
+		if err != nil {
+			return err
+		}
+		return e.push(b)
+	case OpSwap:
+		a, err := e.pop()
+		if err != nil {
+			return err
+		}
+		b, err := e.pop()
+		if err != nil {
+			return err
+		}
+		e.stack = append(e.stack, a, b)
+		return nil
+	case OpSize:
+		b, err := e.peek()
+		if err != nil {
+			return err
+		}
+		return e.push(encodeNum(int64(len(b))))
+	case OpEqual, OpEqualVerify:
+		a, err := e.pop()
+		if err != nil {
+			return err
+		}
+		b, err := e.pop()
+		if err != nil {
+			return err
+		}
+		if err := e.pushBool(bytes.Equal(a, b)); err != nil {
+			return err
+		}
+		if in.op == OpEqualVerify {
+			return e.verify()
+		}
+		return nil
+	case OpLessThan, OpGreaterThan, OpLessThanOrEqual, OpGreaterThanOrEqual:
+		b, err := e.popNum()
+		if err != nil {
+			return err
+		}
+		a, err := e.popNum()
+		if err != nil {
+			return err
+		}
+		var result bool
+		switch in.op {
+		case OpLessThan:
+			result = a < b
+		case OpGreaterThan:
+			result = a > b
+		case OpLessThanOrEqual:
+			result = a <= b
+		case OpGreaterThanOrEqual:
+			result = a >= b
+		}
+		return e.pushBool(result)
+	case OpSHA256:
+		b, err := e.pop()
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(b)
+		return e.push(hash[:])
+	case OpAddress:
+		b, err := e.pop()
+		if err != nil {
+			return err
+		}
+		pubKey, err := crypto.ParsePublicKey(b)
+		if err != nil {
+			return err
+		}
+		return e.push(pubKey.Address().Bytes())
+	case OpCheckSig, OpCheckSigVerify:
+		pubKey, err := e.pop()
+		if err != nil {
+			return err
+		}
+		sig, err := e.pop()
+		if err != nil {
+			return err
+		}
+		if err := e.pushBool(e.checkSig(pubKey, sig)); err != nil {
+			return err
+		}
+		if in.op == OpCheckSigVerify {
+			return e.verify()
+		}
+		return nil
+	case OpCheckLockTimeVerify:
+		b, err := e.peek()
+		if err != nil {
+			return err
+		}
+		height, err := decodeNum(b)
+		if err != nil {
+			return err
+		}
+		if height < 0 {
+			return fmt.Errorf("negative lock height %d", height)
+		}
+		if e.ctx.Height < height {
+			return fmt.Errorf("output is locked until height %d", height)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown opcode")
+}
+
+func (e *engine) verify() error {
+	b, err := e.pop()
+	if err != nil {
+		return err
+	}
+	if !asBool(b) {
+		return ErrScriptFailed
+	}
+	return nil
+}
+
+func (e *engine) checkSig(pubKey, sig []byte) bool {
+	key, err := crypto.ParsePublicKey(pubKey)
+	if err != nil {
+		return false
+	}
+	signature, err := crypto.ParseSignature(sig)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(key, e.ctx.SigHash)
+}
@@ -0,0 +1,91 @@
+package script
+
+import "fmt"
+
+type Opcode byte
+
+const (
+	// Op0 pushes an empty item, which counts as false and as the number 0.
+	Op0 Opcode = 0x00
+	// Opcodes 0x01 to 0x4b push the next n bytes of the script.
+	OpPushData1 Opcode = 0x4c // next byte is the length of the data
+	OpPushData2 Opcode = 0x4d // next two bytes (little endian) are the length of the data
+	OpPushData4 Opcode = 0x4e // next four bytes (little endian) are the length of the data
+	Op1Negate   Opcode = 0x4f
+	// OpReserved is not a valid opcode, scripts executing it fail.
+	OpReserved Opcode = 0x50
+	Op1        Opcode = 0x51 // Op1 to Op16 push the numbers 1 to 16
+	Op16       Opcode = 0x60
+
+	OpNop    Opcode = 0x61
+	OpIf     Opcode = 0x63
+	OpNotIf  Opcode = 0x64
+	OpElse   Opcode = 0x67
+	OpEndIf  Opcode = 0x68
+	OpVerify Opcode = 0x69
+	OpReturn Opcode = 0x6a
+
+	OpDrop Opcode = 0x75
+	OpDup  Opcode = 0x76
+	OpSwap Opcode = 0x7c
+	OpSize Opcode = 0x82
+
+	OpEqual       Opcode = 0x87
+	OpEqualVerify Opcode = 0x88
+
+	OpLessThan           Opcode = 0x9f
+	OpGreaterThan        Opcode = 0xa0
+	OpLessThanOrEqual    Opcode = 0xa1
+	OpGreaterThanOrEqual Opcode = 0xa2
+
+	OpSHA256 Opcode = 0xa8
+	// OpAddress replaces a public key with the address derived from it.
+	OpAddress        Opcode = 0xa9
+	OpCheckSig       Opcode = 0xac
+	OpCheckSigVerify Opcode = 0xad
+
+	// OpCheckLockTimeVerify fails unless the spending transaction is included
+	// at or above the height on top of the stack. It leaves the stack as is.
+	OpCheckLockTimeVerify Opcode = 0xb1
+)
+
+var opcodeNames = map[Opcode]string{
+	Op0:                   "OP_0",
+	OpPushData1:           "OP_PUSHDATA1",
+	OpPushData2:           "OP_PUSHDATA2",
+	OpPushData4:           "OP_PUSHDATA4",
+	OpReserved:            "OP_RESERVED",
+	Op1Negate:             "OP_1NEGATE",
+	OpNop:                 "OP_NOP",
+	OpIf:                  "OP_IF",
+	OpNotIf:               "OP_NOTIF",
+	OpElse:                "OP_ELSE",
+	OpEndIf:               "OP_ENDIF",
+	OpVerify:              "OP_VERIFY",
+	OpReturn:              "OP_RETURN",
+	OpDrop:                "OP_DROP",
+	OpDup:                 "OP_DUP",
+	OpSwap:                "OP_SWAP",
+	OpSize:                "OP_SIZE",
+	OpEqual:               "OP_EQUAL",
+	OpEqualVerify:         "OP_EQUALVERIFY",
+	OpLessThan:            "OP_LESSTHAN",
+	OpGreaterThan:         "OP_GREATERTHAN",
+	OpLessThanOrEqual:     "OP_LESSTHANOREQUAL",
+	OpGreaterThanOrEqual:  "OP_GREATERTHANOREQUAL",
+	OpSHA256:              "OP_SHA256",
+	OpAddress:             "OP_ADDRESS",
+	OpCheckSig:            "OP_CHECKSIG",
+	OpCheckSigVerify:      "OP_CHECKSIGVERIFY",
+	OpCheckLockTimeVerify: "OP_CHECKLOCKTIMEVERIFY",
+}
+
+func (op Opcode) String() string {
+	if name, ok := opcodeNames[op]; ok {
+		return name
+	}
+	if op >= Op1 && op <= Op16 {
+		return fmt.Sprintf("OP_%d", op-Op1+1)
+	}
+	return fmt.Sprintf("OP_UNKNOWN_%#x", byte(op))
+}
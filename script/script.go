@@ -0,0 +1,226 @@
+// Package script implements the locking scripts carried by transaction
+// outputs and the stack machine that checks the unlocking scripts spending
+// them.
+//
+// A script is a sequence of opcodes and data pushes. To spend an output, the
+// unlocking script of the input is run first, then the locking script of the
+// output on the resulting stack. The spend is valid when neither fails and the
+// top of the final stack is true.
+package script
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MaxScriptSize  = 10000
+	MaxElementSize = 520
+	MaxStackSize   = 1000
+	MaxOps         = 201
+	// maxNumLen is the maximum byte length of a number operand.
+	maxNumLen = 8
+)
+
+var ErrMalformedScript = errors.New("malformed script")
+
+type instruction struct {
+	op   Opcode
+	data []byte
+}
+
+func parse(script []byte) ([]instruction, error) {
+	if len(script) > MaxScriptSize {
+		return nil, fmt.Errorf("script size %d exceeds %d", len(script), MaxScriptSize)
+	}
+
+	var instructions []instruction
+	for i := 0; i < len(script); {
+		op := Opcode(script[i])
+		i++
+
+		var n int
+		switch {
+		case op > Op0 && op < OpPushData1:
+			n = int(op)
+		case op == OpPushData1:
+			if i+1 > len(script) {
+				return nil, ErrMalformedScript
+			}
+			n = int(script[i])
+			i++
+		case op == OpPushData2:
+			if i+2 > len(script) {
+				return nil, ErrMalformedScript
+			}
+			n = int(binary.LittleEndian.Uint16(script[i:]))
+			i += 2
+		case op == OpPushData4:
+			if i+4 > len(script) {
+				return nil, ErrMalformedScript
+			}
+			length := binary.LittleEndian.Uint32(script[i:])
+			i += 4
+			if uint64(length) > uint64(len(script)-i) {
+				return nil, ErrMalformedScript
+			}
+			n = int(length)
+		default:
+			instructions = append(instructions, instruction{op: op})
+			continue
+		}
+
+		if i+n > len(script) {
+			return nil, ErrMalformedScript
+		}
+		instructions = append(instructions, instruction{op: op, data: script[i : i+n]})
+		i += n
+	}
+	return instructions, nil
+}
+
+func (in instruction) isPush() bool {
+	return in.op <= OpPushData4 || in.op == Op1Negate || (in.op >= Op1 && in.op <= Op16)
+}
+
+// IsPushOnly reports whether the script only pushes data onto the stack.
+func IsPushOnly(script []byte) bool {
+	instructions, err := parse(script)
+	if err != nil {
+		return false
+	}
+	for _, in := range instructions {
+		if !in.isPush() {
+			return false
+		}
+	}
+	return true
+}
+
+// Disassemble returns a human readable form of the script.
+func Disassemble(script []byte) (string, error) {
+	instructions, err := parse(script)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(instructions))
+	for i, in := range instructions {
+		if in.data != nil {
+			parts[i] = hex.EncodeToString(in.data)
+			continue
+		}
+		parts[i] = in.op.String()
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// Builder assembles a script from opcodes and data, always using the smallest
+// push encoding.
+type Builder struct {
+	script []byte
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) AddOp(op Opcode) *Builder {
+	b.script = append(b.script, byte(op))
+	return b
+}
+
+func (b *Builder) AddData(data []byte) *Builder {
+	switch n := len(data); {
+	case n == 0:
+		b.script = append(b.script, byte(Op0))
+	case n < int(OpPushData1):
+		b.script = append(b.script, byte(n))
+	case n <= 0xff:
+		b.script = append(b.script, byte(OpPushData1), byte(n))
+	default:
+		b.script = append(b.script, byte(OpPushData2), byte(n), byte(n>>8))
+	}
+	b.script = append(b.script, data...)
+	return b
+}
+
+func (b *Builder) AddInt(n int64) *Builder {
+	switch {
+	case n == 0:
+		return b.AddOp(Op0)
+	case n == -1:
+		return b.AddOp(Op1Negate)
+	case n >= 1 && n <= 16:
+		return b.AddOp(Op1 + Opcode(n-1))
+	}
+	return b.AddData(encodeNum(n))
+}
+
+func (b *Builder) Script() []byte {
+	return b.script
+}
+
+// encodeNum encodes n as little endian sign-magnitude using as few bytes as
+// possible. Zero is the empty byte slice.
+func encodeNum(n int64) []byte {
+	if n == 0 {
+		return []byte{}
+	}
+
+	negative := n < 0
+	abs := uint64(n)
+	if negative {
+		abs = uint64(-n)
+	}
+
+	var b []byte
+	for abs > 0 {
+		b = append(b, byte(abs))
+		abs >>= 8
+	}
+
+	if b[len(b)-1]&0x80 != 0 {
+		extra := byte(0x00)
+		if negative {
+			extra = 0x80
+		}
+		b = append(b, extra)
+	} else if negative {
+		b[len(b)-1] |= 0x80
+	}
+	return b
+}
+
+func decodeNum(b []byte) (int64, error) {
+	if len(b) > maxNumLen {
+		return 0, fmt.Errorf("number of %d bytes exceeds %d", len(b), maxNumLen)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	var n uint64
+	for i, v := range b {
+		n |= uint64(v) << (8 * i)
+	}
+
+	signBit := uint64(0x80) << (8 * (len(b) - 1))
+	if n&signBit != 0 {
+		return -int64(n &^ signBit), nil
+	}
+	return int64(n), nil
+}
+
+func asBool(b []byte) bool {
+	for i, v := range b {
+		if v != 0 {
+			// negative zero is false as well
+			return !(i == len(b)-1 && v == 0x80)
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package script
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumEncoding(t *testing.T) {
+	for _, n := range []int64{0, 1, -1, 127, 128, -128, 255, 256, 1 << 31, -(1 << 40)} {
+		decoded, err := decodeNum(encodeNum(n))
+		require.Nil(t, err)
+		assert.Equal(t, n, decoded)
+	}
+	assert.Equal(t, []byte{}, encodeNum(0))
+	assert.Equal(t, []byte{0x80, 0x00}, encodeNum(128))
+	assert.Equal(t, []byte{0x81}, encodeNum(-1))
+}
+
+func TestPayToAddress(t *testing.T) {
+	var (
+		privKey = crypto.GeneratePrivateKey()
+		pubKey  = privKey.Public()
+		lock    = PayToAddress(pubKey.Address())
+		ctx     = &Context{SigHash: util.RandomHash()}
+	)
+
+	addr, ok := ExtractAddress(lock)
+	require.True(t, ok)
+	assert.Equal(t, pubKey.Address().Bytes(), addr.Bytes())
+
+	unlock := SignatureScript(privKey.Sign(ctx.SigHash), pubKey)
+	assert.Nil(t, Execute(unlock, lock, ctx))
+
+	// signed by someone else
+	otherKey := crypto.GeneratePrivateKey()
+	unlock = SignatureScript(otherKey.Sign(ctx.SigHash), otherKey.Public())
+	assert.NotNil(t, Execute(unlock, lock, ctx))
+
+	// right key, wrong message
+	unlock = SignatureScript(privKey.Sign(util.RandomHash()), pubKey)
+	assert.ErrorIs(t, Execute(unlock, lock, ctx), ErrScriptFailed)
+
+	disasm, err := Disassemble(lock)
+	require.Nil(t, err)
+	assert.Contains(t, disasm, "OP_DUP OP_ADDRESS")
+}
+
+func TestUnlockScriptMustBePushOnly(t *testing.T) {
+	lock := NewBuilder().AddOp(Op1).Script()
+	unlock := NewBuilder().AddOp(OpDup).Script()
+	assert.NotNil(t, Execute(unlock, lock, &Context{}))
+}
+
+func TestHashLockWithTimeout(t *testing.T) {
+	preimage := []byte("secret")
+	hash := sha256.Sum256(preimage)
+
+	lock := NewBuilder().
+		AddOp(OpIf).
+		AddOp(OpSHA256).AddData(hash[:]).AddOp(OpEqual).
+		AddOp(OpElse).
+		AddInt(10).AddOp(OpCheckLockTimeVerify).AddOp(OpDrop).AddOp(Op1).
+		AddOp(OpEndIf).
+		Script()
+
+	withPreimage := NewBuilder().AddData(preimage).AddOp(Op1).Script()
+	assert.Nil(t, Execute(withPreimage, lock, &Context{Height: 1}))
+
+	wrongPreimage := NewBuilder().AddData([]byte("guess")).AddOp(Op1).Script()
+	assert.ErrorIs(t, Execute(wrongPreimage, lock, &Context{Height: 1}), ErrScriptFailed)
+
+	timeout := NewBuilder().AddOp(Op0).Script()
+	assert.NotNil(t, Execute(timeout, lock, &Context{Height: 9}))
+	assert.Nil(t, Execute(timeout, lock, &Context{Height: 10}))
+}
+
+func TestComparisons(t *testing.T) {
+	lock := NewBuilder().AddInt(1000).AddOp(OpLessThan).Script()
+	assert.Nil(t, Execute(NewBuilder().AddInt(999).Script(), lock, &Context{}))
+	assert.NotNil(t, Execute(NewBuilder().AddInt(1000).Script(), lock, &Context{}))
+	assert.NotNil(t, Execute(NewBuilder().AddInt(-5000).AddInt(1).Script(), NewBuilder().AddOp(OpGreaterThan).Script(), &Context{}))
+}
+
+func TestMalformedScripts(t *testing.T) {
+	_, err := Disassemble([]byte{0x05, 0x01})
+	assert.ErrorIs(t, err, ErrMalformedScript)
+
+	unbalanced := NewBuilder().AddOp(Op1).AddOp(OpIf).AddOp(Op1).Script()
+	assert.NotNil(t, Execute(nil, unbalanced, &Context{}))
+
+	assert.NotNil(t, Execute(nil, NewBuilder().AddOp(OpReturn).Script(), &Context{}))
+	assert.NotNil(t, Execute(nil, []byte{0xff}, &Context{}))
+}
+
+func TestPushData4(t *testing.T) {
+	unlock := []byte{byte(OpPushData4), 1, 0, 0, 0, 5}
+	assert.True(t, IsPushOnly(unlock))
+	lock := NewBuilder().AddInt(5).AddOp(OpEqual).Script()
+	assert.Nil(t, Execute(unlock, lock, &Context{}))
+
+	_, err := Disassemble([]byte{byte(OpPushData4), 2, 0, 0, 0, 5})
+	assert.ErrorIs(t, err, ErrMalformedScript)
+	_, err = Disassemble([]byte{byte(OpPushData4), 0xff, 0xff, 0xff, 0xff})
+	assert.ErrorIs(t, err, ErrMalformedScript)
+}
+
+func TestReservedOpcode(t *testing.T) {
+	assert.False(t, IsPushOnly([]byte{byte(OpReserved)}))
+	assert.NotNil(t, Execute(nil, []byte{byte(Op1), byte(OpReserved)}, &Context{}))
+}
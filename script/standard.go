@@ -0,0 +1,48 @@
+package script
+
+import (
+	"bytes"
+
+	"github.com/mhg14/ChlockBane/crypto"
+)
+
+// PayToAddress returns the standard pay-to-pubkey-hash locking script, which
+// can be spent by the owner of the public key behind addr.
+func PayToAddress(addr crypto.Address) []byte {
+	return NewBuilder().
+		AddOp(OpDup).
+		AddOp(OpAddress).
+		AddData(addr.Bytes()).
+		AddOp(OpEqualVerify).
+		AddOp(OpCheckSig).
+		Script()
+}
+
+// ExtractAddress returns the address paid to by a PayToAddress script.
+func ExtractAddress(lockScript []byte) (crypto.Address, bool) {
+	instructions, err := parse(lockScript)
+	if err != nil || len(instructions) != 5 {
+		return crypto.Address{}, false
+	}
+	if instructions[0].op != OpDup ||
+		instructions[1].op != OpAddress ||
+		len(instructions[2].data) != crypto.AddressLen ||
+		instructions[3].op != OpEqualVerify ||
+		instructions[4].op != OpCheckSig {
+		return crypto.Address{}, false
+	}
+	return crypto.AddressFromBytes(instructions[2].data), true
+}
+
+// SignatureScript returns the unlocking script for a PayToAddress output.
+func SignatureScript(sig *crypto.Signature, pubKey *crypto.PublicKey) []byte {
+	return NewBuilder().
+		AddData(sig.Bytes()).
+		AddData(pubKey.Bytes()).
+		Script()
+}
+
+func IsPayToAddress(lockScript []byte, addr crypto.Address) bool {
+	extracted, ok := ExtractAddress(lockScript)
+	return ok && bytes.Equal(extracted.Bytes(), addr.Bytes())
+}
@@ -0,0 +1,168 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Server is the signer daemon. It holds the validator key and signs headers
+// for a node connecting over a unix socket.
+type Server struct {
+	proto.UnimplementedSignerServer
+
+	signer *LocalSigner
+}
+
+func NewServer(signer *LocalSigner) *Server {
+	return &Server{
+		signer: signer,
+	}
+}
+
+// ListenAndServe serves on a unix socket at path, which only the owner may
+// connect to. A stale socket left at path is removed first.
+func (s *Server) ListenAndServe(path string) error {
+	ln, err := listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	return s.Serve(ln)
+}
+
+// listen creates the socket in a directory only the owner can enter and moves
+// it to path once its permissions are restricted, so others never get a chance
+// to connect while it still has the permissions of the umask.
+func listen(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".signer-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "signer.sock")
+	ln, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// the socket is moved away from tmp, so the listener can't remove it
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	grpcServer := grpc.NewServer()
+	proto.RegisterSignerServer(grpcServer, s)
+	return grpcServer.Serve(ln)
+}
+
+func (s *Server) GetPublicKey(ctx context.Context, req *proto.PublicKeyRequest) (*proto.PublicKeyResponse, error) {
+	return &proto.PublicKeyResponse{
+		PublicKey: s.signer.PublicKey().Bytes(),
+	}, nil
+}
+
+func (s *Server) SignHeader(ctx context.Context, req *proto.SignHeaderRequest) (*proto.SignHeaderResponse, error) {
+	if req.Header == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing header")
+	}
+
+	sig, err := s.signer.SignHeader(ctx, req.Header)
+	if errors.Is(err, ErrDoubleSign) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &proto.SignHeaderResponse{
+		Signature: sig.Bytes(),
+	}, nil
+}
+
+// RemoteSigner signs through a signer daemon.
+type RemoteSigner struct {
+	conn   *grpc.ClientConn
+	client proto.SignerClient
+	pubKey *crypto.PublicKey
+}
+
+// DialRemoteSigner connects to the signer daemon listening on the unix socket
+// at path and fetches its public key.
+func DialRemoteSigner(ctx context.Context, path string) (*RemoteSigner, error) {
+	conn, err := grpc.DialContext(ctx, "unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	client := proto.NewSignerClient(conn)
+	resp, err := client.GetPublicKey(ctx, &proto.PublicKeyRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	pubKey, err := crypto.ParsePublicKey(resp.PublicKey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &RemoteSigner{
+		conn:   conn,
+		client: client,
+		pubKey: pubKey,
+	}, nil
+}
+
+func (s *RemoteSigner) PublicKey() *crypto.PublicKey {
+	return s.pubKey
+}
+
+// SignHeader asks the daemon to sign the header. The returned signature is
+// checked, so a misbehaving daemon can't make the node publish a block with an
+// invalid signature.
+func (s *RemoteSigner) SignHeader(ctx context.Context, header *proto.Header) (*crypto.Signature, error) {
+	resp, err := s.client.SignHeader(ctx, &proto.SignHeaderRequest{Header: header})
+	if status.Code(err) == codes.FailedPrecondition {
+		return nil, fmt.Errorf("%w: %s", ErrDoubleSign, status.Convert(err).Message())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.ParseSignature(resp.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if !sig.Verify(s.pubKey, types.HashHeader(header)) {
+		return nil, fmt.Errorf("signer returned an invalid signature")
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) Close() error {
+	return s.conn.Close()
+}
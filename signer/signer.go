@@ -0,0 +1,170 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// ErrDoubleSign is returned when signing a header would let the validator sign
+// two different blocks at the same height.
+var ErrDoubleSign = errors.New("refusing to double sign")
+
+// Signer signs block headers on behalf of a validator.
+type Signer interface {
+	PublicKey() *crypto.PublicKey
+	SignHeader(ctx context.Context, header *proto.Header) (*crypto.Signature, error)
+}
+
+// SignBlock sets the root hash of the block, has the signer sign its header and
+// attaches the signature to the block.
+func SignBlock(ctx context.Context, s Signer, block *proto.Block) error {
+	if err := types.SetRootHash(block); err != nil {
+		return err
+	}
+	sig, err := s.SignHeader(ctx, block.Header)
+	if err != nil {
+		return err
+	}
+	block.PublicKey = s.PublicKey().Bytes()
+	block.Signature = sig.Bytes()
+	return nil
+}
+
+// LocalSigner signs with a key held in process memory.
+type LocalSigner struct {
+	privKey *crypto.PrivateKey
+	guard   *guard
+}
+
+// NewLocalSigner returns a signer for the key. When statePath is set, the last
+// signed header is persisted there, so double sign protection survives a
+// restart.
+func NewLocalSigner(privKey *crypto.PrivateKey, statePath string) (*LocalSigner, error) {
+	g, err := newGuard(statePath)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{
+		privKey: privKey,
+		guard:   g,
+	}, nil
+}
+
+func (s *LocalSigner) PublicKey() *crypto.PublicKey {
+	return s.privKey.Public()
+}
+
+func (s *LocalSigner) SignHeader(ctx context.Context, header *proto.Header) (*crypto.Signature, error) {
+	hash := types.HashHeader(header)
+	if err := s.guard.approve(header.Height, hash); err != nil {
+		return nil, err
+	}
+	return s.privKey.Sign(hash), nil
+}
+
+// signState is the last header a signer signed.
+type signState struct {
+	Height     int32  `json:"height"`
+	HeaderHash string `json:"headerHash"`
+}
+
+// guard refuses to sign a header at a height below the last signed header, or
+// a different header at the same height.
+type guard struct {
+	lock  sync.Mutex
+	path  string
+	state *signState
+}
+
+func newGuard(path string) (*guard, error) {
+	g := &guard{
+		path: path,
+	}
+	if path == "" {
+		return g, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &signState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid signer state %s: %w", path, err)
+	}
+	g.state = state
+	return g, nil
+}
+
+// approve records the header as signed, unless that would double sign. The
+// state is persisted before the header is approved.
+func (g *guard) approve(height int32, hash []byte) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.state != nil {
+		if height < g.state.Height {
+			return fmt.Errorf("%w: height %d is below the last signed height %d", ErrDoubleSign, height, g.state.Height)
+		}
+		if height == g.state.Height {
+			last, err := hex.DecodeString(g.state.HeaderHash)
+			if err != nil || !bytes.Equal(last, hash) {
+				return fmt.Errorf("%w: already signed a different block at height %d", ErrDoubleSign, height)
+			}
+			return nil
+		}
+	}
+
+	state := &signState{
+		Height:     height,
+		HeaderHash: hex.EncodeToString(hash),
+	}
+	if err := g.persist(state); err != nil {
+		return err
+	}
+	g.state = state
+	return nil
+}
+
+func (g *guard) persist(state *signState) error {
+	if g.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(g.path), filepath.Base(g.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), g.path)
+}
@@ -0,0 +1,112 @@
+package signer
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomHeader(height int32) *proto.Header {
+	return &proto.Header{
+		Version:  1,
+		Height:   height,
+		PrevHash: util.RandomHash(),
+	}
+}
+
+func TestLocalSignerDoubleSign(t *testing.T) {
+	var (
+		privKey   = crypto.GeneratePrivateKey()
+		statePath = filepath.Join(t.TempDir(), "state.json")
+		header    = randomHeader(5)
+	)
+
+	s, err := NewLocalSigner(privKey, statePath)
+	require.Nil(t, err)
+
+	sig, err := s.SignHeader(context.Background(), header)
+	require.Nil(t, err)
+	assert.True(t, sig.Verify(privKey.Public(), types.HashHeader(header)))
+
+	// signing the same header again is fine
+	_, err = s.SignHeader(context.Background(), header)
+	require.Nil(t, err)
+
+	_, err = s.SignHeader(context.Background(), randomHeader(5))
+	assert.ErrorIs(t, err, ErrDoubleSign)
+	_, err = s.SignHeader(context.Background(), randomHeader(4))
+	assert.ErrorIs(t, err, ErrDoubleSign)
+
+	// the protection survives a restart
+	s, err = NewLocalSigner(privKey, statePath)
+	require.Nil(t, err)
+	_, err = s.SignHeader(context.Background(), randomHeader(5))
+	assert.ErrorIs(t, err, ErrDoubleSign)
+	_, err = s.SignHeader(context.Background(), randomHeader(6))
+	assert.Nil(t, err)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	var (
+		privKey = crypto.GeneratePrivateKey()
+		socket  = filepath.Join(t.TempDir(), "signer.sock")
+	)
+
+	local, err := NewLocalSigner(privKey, "")
+	require.Nil(t, err)
+	ln, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	go NewServer(local).Serve(ln)
+	defer ln.Close()
+
+	remote, err := DialRemoteSigner(context.Background(), socket)
+	require.Nil(t, err)
+	defer remote.Close()
+	assert.Equal(t, privKey.Public().Bytes(), remote.PublicKey().Bytes())
+
+	block := util.RandomBlock()
+	block.Header.Height = 1
+	block.Transactions = []*proto.Transaction{{Version: 1}}
+	require.Nil(t, SignBlock(context.Background(), remote, block))
+	assert.True(t, types.VerifyBlock(block))
+	assert.Equal(t, privKey.Public().Bytes(), block.PublicKey)
+
+	other := util.RandomBlock()
+	other.Header.Height = 1
+	err = SignBlock(context.Background(), remote, other)
+	assert.ErrorIs(t, err, ErrDoubleSign)
+	assert.Nil(t, other.Signature)
+}
+
+func TestListenRestrictsSocket(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		socket = filepath.Join(dir, "signer.sock")
+	)
+
+	ln, err := listen(socket)
+	require.Nil(t, err)
+	defer ln.Close()
+
+	info, err := os.Stat(socket)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the private directory the socket was created in is gone
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	conn, err := net.Dial("unix", socket)
+	require.Nil(t, err)
+	conn.Close()
+}
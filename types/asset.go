@@ -0,0 +1,64 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// AssetIDLen is the length of asset IDs. Outputs of the native coin have an
+// empty asset ID.
+const AssetIDLen = 32
+
+// MaxAssetNameLen is the longest name an asset issuance may carry.
+const MaxAssetNameLen = 64
+
+// AssetID returns the ID of the asset issued by a transaction whose first input
+// spends the given outpoint.
+func AssetID(prevTxHash []byte, prevOutIndex uint32) []byte {
+	h := sha256.New()
+	h.Write([]byte("asset"))
+	h.Write(prevTxHash)
+	h.Write(binary.BigEndian.AppendUint32(nil, prevOutIndex))
+	return h.Sum(nil)
+}
+
+// IssuedAssetID returns the ID of the asset issued by the transaction.
+func IssuedAssetID(tx *proto.Transaction) ([]byte, error) {
+	if tx.GetIssuance() == nil {
+		return nil, fmt.Errorf("transaction issues no asset")
+	}
+	if len(tx.Inputs) == 0 {
+		return nil, fmt.Errorf("asset issuance needs an input")
+	}
+	return AssetID(tx.Inputs[0].PrevTxHash, tx.Inputs[0].PrevOutIndex), nil
+}
+
+// NewIssuanceTransaction returns an unsigned transaction issuing amount of a new
+// asset. The caller adds the outputs, which must hold exactly that amount of
+// the asset, and any outputs for the native coin the inputs carry.
+func NewIssuanceTransaction(name string, amount int64, inputs ...*proto.TxInput) *proto.Transaction {
+	return &proto.Transaction{
+		Version: TransactionVersion,
+		Inputs:  inputs,
+		Payload: &proto.Transaction_Issuance{
+			Issuance: &proto.AssetIssuance{
+				Amount: amount,
+				Name:   name,
+			},
+		},
+	}
+}
+
+// ValidateIssuance checks the issuance payload itself.
+func ValidateIssuance(issuance *proto.AssetIssuance) error {
+	if issuance.Amount <= 0 {
+		return fmt.Errorf("invalid issuance amount %d", issuance.Amount)
+	}
+	if len(issuance.Name) > MaxAssetNameLen {
+		return fmt.Errorf("asset name longer than %d bytes", MaxAssetNameLen)
+	}
+	return nil
+}
@@ -12,6 +12,13 @@ import (
 	pb "google.golang.org/protobuf/proto"
 )
 
+// BlockVersion is the highest block version this software knows the rules of.
+const BlockVersion = 1
+
+func IsKnownBlockVersion(version int32) bool {
+	return version >= 1 && version <= BlockVersion
+}
+
 type TxHash struct {
 	hash []byte
 }
@@ -32,12 +39,8 @@ func (h TxHash) Equals(other merkletree.Content) (bool, error) {
 }
 
 func SignBlock(privKey *crypto.PrivateKey, block *proto.Block) *crypto.Signature {
-	if len(block.Transactions) > 0 {
-		tree, err := GetMerkleTree(block)
-		if err != nil {
-			panic(err)
-		}
-		block.Header.RootHash = tree.MerkleRoot()
+	if err := SetRootHash(block); err != nil {
+		panic(err)
 	}
 
 	hash := HashBlock(block)
@@ -48,6 +51,20 @@ func SignBlock(privKey *crypto.PrivateKey, block *proto.Block) *crypto.Signature
 	return signature
 }
 
+// SetRootHash sets the root hash of the header to the merkle root of the
+// transactions of the block. It has to be called before the block is signed.
+func SetRootHash(block *proto.Block) error {
+	if len(block.Transactions) == 0 {
+		return nil
+	}
+	tree, err := GetMerkleTree(block)
+	if err != nil {
+		return err
+	}
+	block.Header.RootHash = tree.MerkleRoot()
+	return nil
+}
+
 // This function returns a SHA256 of only the block header
 func HashBlock(block *proto.Block) []byte {
 	return HashHeader(block.Header)
@@ -63,6 +80,11 @@ func HashHeader(header *proto.Header) []byte {
 }
 
 func VerifyBlock(b *proto.Block) bool {
+	if b.Header == nil {
+		log.Println("MISSING BLOCK HEADER")
+		return false
+	}
+
 	if len(b.Transactions) > 0 {
 		if !VerifyRootHash(b) {
 			log.Println("INVALID ROOT HASH")
@@ -70,17 +92,17 @@ func VerifyBlock(b *proto.Block) bool {
 		}
 	}
 
-	if len(b.PublicKey) != crypto.PublicKeyLen {
+	pubKey, err := crypto.ParsePublicKey(b.PublicKey)
+	if err != nil {
 		log.Println("INVALID PUBLIC KEY LENGTH")
 		return false
 	}
 
-	if len(b.Signature) != crypto.SigLen {
+	sig, err := crypto.ParseSignature(b.Signature)
+	if err != nil {
 		log.Println("INVALID SIGNATURE LENGTH")
 		return false
 	}
-	sig := crypto.SignatureFromBytes(b.Signature)
-	pubKey := crypto.PublicKeyFromBytes(b.PublicKey)
 	hash := HashBlock(b)
 	return sig.Verify(pubKey, hash)
 }
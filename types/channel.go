@@ -0,0 +1,73 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// NewChannelOutput returns an output funding a payment channel from payer to
+// payee, refundable to the payer from the timeout height on.
+func NewChannelOutput(capacity int64, payer, payee *crypto.PublicKey, timeout int32) *proto.TxOutput {
+	return &proto.TxOutput{
+		Amount: capacity,
+		Channel: &proto.ChannelLock{
+			Payer:   payer.Bytes(),
+			Payee:   payee.Bytes(),
+			Timeout: timeout,
+		},
+	}
+}
+
+func ValidateChannelLock(lock *proto.ChannelLock) error {
+	if len(lock.Payer) != crypto.PublicKeyLen || len(lock.Payee) != crypto.PublicKeyLen {
+		return fmt.Errorf("invalid channel public key length")
+	}
+	if bytes.Equal(lock.Payer, lock.Payee) {
+		return fmt.Errorf("channel payer and payee must differ")
+	}
+	if lock.Timeout <= 0 {
+		return fmt.Errorf("invalid channel timeout %d", lock.Timeout)
+	}
+	return nil
+}
+
+// VerifyChannelSpend checks that the input may spend the channel output in a
+// block at the given height. Closing the channel takes two signatures in
+// input.Signatures, the payer's followed by the payee's. A refund is signed by
+// the payer alone, as a plain input signature verified with the others.
+func VerifyChannelSpend(lock *proto.ChannelLock, input *proto.TxInput, sigHash []byte, height int) error {
+	if len(input.UnlockScript) > 0 || len(input.Preimage) > 0 {
+		return fmt.Errorf("channel output must be spent with signatures only")
+	}
+
+	if len(input.Signatures) > 0 {
+		if len(input.Signature) > 0 || len(input.Signatures) != 2 {
+			return fmt.Errorf("channel close needs the signatures of payer and payee")
+		}
+		for i, pubKey := range [][]byte{lock.Payer, lock.Payee} {
+			key, err := crypto.ParsePublicKey(pubKey)
+			if err != nil {
+				return err
+			}
+			sig, err := crypto.ParseSignature(input.Signatures[i])
+			if err != nil {
+				return err
+			}
+			if !sig.Verify(key, sigHash) {
+				return fmt.Errorf("invalid channel close signature %d", i)
+			}
+		}
+		return nil
+	}
+
+	if !bytes.Equal(input.PublicKey, lock.Payer) {
+		return fmt.Errorf("only the payer can refund the channel")
+	}
+	if height < int(lock.Timeout) {
+		return fmt.Errorf("channel can't be refunded before height %d", lock.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// MaxDataLen is the most bytes a data output may carry.
+const MaxDataLen = 80
+
+// AnchorLen is the length of data the chain indexes as an anchored hash.
+const AnchorLen = 32
+
+// NewDataOutput returns an unspendable output carrying data, such as the hash
+// of a document to timestamp.
+func NewDataOutput(data []byte) *proto.TxOutput {
+	return &proto.TxOutput{
+		Data: data,
+	}
+}
+
+func IsDataOutput(output *proto.TxOutput) bool {
+	return len(output.Data) > 0
+}
+
+// ValidateDataOutput checks that the data output is within the size limit and
+// carries nothing but its data.
+func ValidateDataOutput(output *proto.TxOutput) error {
+	if len(output.Data) > MaxDataLen {
+		return fmt.Errorf("data output of %d bytes exceeds %d", len(output.Data), MaxDataLen)
+	}
+	if output.Amount != 0 || len(output.Address) > 0 || len(output.Asset) > 0 {
+		return fmt.Errorf("data output can't carry an amount or address")
+	}
+	if len(output.LockScript) > 0 || output.Multisig != nil || output.Htlc != nil || output.Channel != nil {
+		return fmt.Errorf("data output can't have a lock")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// SignedHeaderFromBlock strips the transactions of a signed block, keeping only
+// what is needed to prove who signed it.
+func SignedHeaderFromBlock(b *proto.Block) *proto.SignedHeader {
+	return &proto.SignedHeader{
+		Header:    b.Header,
+		PublicKey: b.PublicKey,
+		Signature: b.Signature,
+	}
+}
+
+func VerifySignedHeader(sh *proto.SignedHeader) bool {
+	if sh.Header == nil {
+		return false
+	}
+	pubKey, err := crypto.ParsePublicKey(sh.PublicKey)
+	if err != nil {
+		return false
+	}
+	sig, err := crypto.ParseSignature(sh.Signature)
+	if err != nil {
+		return false
+	}
+	return sig.Verify(pubKey, HashHeader(sh.Header))
+}
+
+func NewDoubleSignTransaction(first, second *proto.SignedHeader) *proto.Transaction {
+	return &proto.Transaction{
+		Version: 1,
+		Payload: &proto.Transaction_Evidence{
+			Evidence: &proto.DoubleSignEvidence{
+				First:  first,
+				Second: second,
+			},
+		},
+	}
+}
+
+// VerifyDoubleSignEvidence checks that the evidence holds two different headers
+// for the same height, both validly signed by the same key. It does not check
+// whether that key was actually a validator at the time.
+func VerifyDoubleSignEvidence(ev *proto.DoubleSignEvidence) error {
+	if ev.First == nil || ev.Second == nil {
+		return fmt.Errorf("evidence is missing a signed header")
+	}
+	if !VerifySignedHeader(ev.First) || !VerifySignedHeader(ev.Second) {
+		return fmt.Errorf("evidence contains an invalid header signature")
+	}
+	if !bytes.Equal(ev.First.PublicKey, ev.Second.PublicKey) {
+		return fmt.Errorf("evidence headers are signed by different keys")
+	}
+	if ev.First.Header.Height != ev.Second.Header.Height {
+		return fmt.Errorf("evidence headers have different heights (%d, %d)", ev.First.Header.Height, ev.Second.Header.Height)
+	}
+	if bytes.Equal(HashHeader(ev.First.Header), HashHeader(ev.Second.Header)) {
+		return fmt.Errorf("evidence headers are identical")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyDoubleSignEvidence(t *testing.T) {
+	var (
+		privKey = crypto.GeneratePrivateKey()
+		first   = util.RandomBlock()
+		second  = util.RandomBlock()
+	)
+	second.Header.Height = first.Header.Height
+	SignBlock(privKey, first)
+	SignBlock(privKey, second)
+
+	tx := NewDoubleSignTransaction(SignedHeaderFromBlock(first), SignedHeaderFromBlock(second))
+	assert.Nil(t, VerifyDoubleSignEvidence(tx.GetEvidence()))
+
+	// the same header twice is not evidence of anything
+	same := NewDoubleSignTransaction(SignedHeaderFromBlock(first), SignedHeaderFromBlock(first))
+	assert.NotNil(t, VerifyDoubleSignEvidence(same.GetEvidence()))
+
+	// headers signed by different keys
+	SignBlock(crypto.GeneratePrivateKey(), second)
+	tx = NewDoubleSignTransaction(SignedHeaderFromBlock(first), SignedHeaderFromBlock(second))
+	assert.NotNil(t, VerifyDoubleSignEvidence(tx.GetEvidence()))
+
+	// headers at different heights
+	second.Header.Height = first.Header.Height + 1
+	SignBlock(privKey, second)
+	tx = NewDoubleSignTransaction(SignedHeaderFromBlock(first), SignedHeaderFromBlock(second))
+	assert.NotNil(t, VerifyDoubleSignEvidence(tx.GetEvidence()))
+}
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"crypto/sha256"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// validatorSetChangePrefix separates governance approvals from everything else
+// validators sign, so an approval can't be replayed as a block or transaction
+// signature, or the other way around.
+const validatorSetChangePrefix = "ChlockBane Validator Set Change:\n"
+
+// HashValidatorSetChange returns the hash validators sign to approve a change.
+func HashValidatorSetChange(change *proto.ValidatorSetChange) []byte {
+	b, err := pb.Marshal(change)
+	if err != nil {
+		panic(err)
+	}
+	h := sha256.New()
+	h.Write([]byte(validatorSetChangePrefix))
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// SignValidatorSetChange returns the approval of a single validator for the
+// given change.
+func SignValidatorSetChange(privKey *crypto.PrivateKey, change *proto.ValidatorSetChange) *proto.GovernanceSignature {
+	return &proto.GovernanceSignature{
+		PublicKey: privKey.Public().Bytes(),
+		Signature: privKey.Sign(HashValidatorSetChange(change)).Bytes(),
+	}
+}
+
+func VerifyGovernanceSignature(change *proto.ValidatorSetChange, sig *proto.GovernanceSignature) bool {
+	pubKey, err := crypto.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return false
+	}
+	signature, err := crypto.ParseSignature(sig.Signature)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(pubKey, HashValidatorSetChange(change))
+}
+
+func NewGovernanceTransaction(change *proto.ValidatorSetChange, sigs ...*proto.GovernanceSignature) *proto.Transaction {
+	return &proto.Transaction{
+		Version: 1,
+		Payload: &proto.Transaction_Governance{
+			Governance: &proto.GovernanceProposal{
+				Change:     change,
+				Signatures: sigs,
+			},
+		},
+	}
+}
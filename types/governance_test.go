@@ -0,0 +1,19 @@
+package types
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/protobuf/proto"
+)
+
+func TestHashValidatorSetChangeIsDomainSeparated(t *testing.T) {
+	change := &proto.ValidatorSetChange{ActivationHeight: 3}
+	b, err := pb.Marshal(change)
+	require.Nil(t, err)
+	hash := sha256.Sum256(b)
+	assert.NotEqual(t, hash[:], HashValidatorSetChange(change))
+}
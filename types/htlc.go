@@ -0,0 +1,70 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// PreimageLen is the only preimage length HTLC outputs accept. Fixing it keeps
+// a preimage valid here from being unusable on the other chain of a swap.
+const PreimageLen = 32
+
+// NewHTLCOutput returns an output the recipient can claim with the preimage of
+// hash before the timeout height, and the sender can reclaim from then on.
+func NewHTLCOutput(amount int64, hash []byte, recipient, sender crypto.Address, timeout int32) *proto.TxOutput {
+	return &proto.TxOutput{
+		Amount: amount,
+		Htlc: &proto.HTLCLock{
+			Hash:      hash,
+			Recipient: recipient.Bytes(),
+			Sender:    sender.Bytes(),
+			Timeout:   timeout,
+		},
+	}
+}
+
+func ValidateHTLCLock(lock *proto.HTLCLock) error {
+	if len(lock.Hash) != sha256.Size {
+		return fmt.Errorf("invalid HTLC hash length %d", len(lock.Hash))
+	}
+	if len(lock.Recipient) != crypto.AddressLen || len(lock.Sender) != crypto.AddressLen {
+		return fmt.Errorf("invalid HTLC address length")
+	}
+	if lock.Timeout <= 0 {
+		return fmt.Errorf("invalid HTLC timeout %d", lock.Timeout)
+	}
+	return nil
+}
+
+// VerifyHTLCSpend checks that the spender, identified by the address of the
+// input public key, may take the HTLC output in a block at the given height.
+func VerifyHTLCSpend(lock *proto.HTLCLock, input *proto.TxInput, spender crypto.Address, height int) error {
+	if len(input.Preimage) > 0 {
+		if len(input.Preimage) != PreimageLen {
+			return fmt.Errorf("invalid preimage length %d", len(input.Preimage))
+		}
+		hash := sha256.Sum256(input.Preimage)
+		if !bytes.Equal(hash[:], lock.Hash) {
+			return fmt.Errorf("preimage does not match the HTLC hash")
+		}
+		if height >= int(lock.Timeout) {
+			return fmt.Errorf("HTLC timed out at height %d", lock.Timeout)
+		}
+		if !bytes.Equal(spender.Bytes(), lock.Recipient) {
+			return fmt.Errorf("only the recipient can claim the HTLC")
+		}
+		return nil
+	}
+
+	if height < int(lock.Timeout) {
+		return fmt.Errorf("HTLC can't be refunded before height %d", lock.Timeout)
+	}
+	if !bytes.Equal(spender.Bytes(), lock.Sender) {
+		return fmt.Errorf("only the sender can refund the HTLC")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+// NewMultisigOutput returns an output that can only be spent with signatures
+// from threshold of the given keys.
+func NewMultisigOutput(amount int64, threshold int, keys ...*crypto.PublicKey) *proto.TxOutput {
+	sorted := make([]*crypto.PublicKey, len(keys))
+	copy(sorted, keys)
+	crypto.SortPublicKeys(sorted)
+
+	lock := &proto.MultisigLock{
+		Threshold: uint32(threshold),
+	}
+	for _, k := range sorted {
+		lock.PublicKeys = append(lock.PublicKeys, k.Bytes())
+	}
+
+	return &proto.TxOutput{
+		Amount:   amount,
+		Address:  crypto.MultisigAddress(threshold, sorted).Bytes(),
+		Multisig: lock,
+	}
+}
+
+// ValidateMultisigLock checks that the lock lists between 1 and
+// crypto.MaxMultisigKeys distinct keys in canonical order, with a threshold
+// that can be met.
+func ValidateMultisigLock(lock *proto.MultisigLock) error {
+	n := len(lock.PublicKeys)
+	if n == 0 || n > crypto.MaxMultisigKeys {
+		return fmt.Errorf("invalid number of multisig keys %d", n)
+	}
+	if lock.Threshold < 1 || int(lock.Threshold) > n {
+		return fmt.Errorf("invalid multisig threshold %d of %d", lock.Threshold, n)
+	}
+	for i, k := range lock.PublicKeys {
+		if len(k) != crypto.PublicKeyLen {
+			return fmt.Errorf("invalid multisig public key length %d", len(k))
+		}
+		if i > 0 && bytes.Compare(lock.PublicKeys[i-1], k) >= 0 {
+			return fmt.Errorf("multisig keys must be sorted and unique")
+		}
+	}
+	return nil
+}
+
+func MultisigLockAddress(lock *proto.MultisigLock) crypto.Address {
+	keys := make([]*crypto.PublicKey, len(lock.PublicKeys))
+	for i, k := range lock.PublicKeys {
+		keys[i] = crypto.PublicKeyFromBytes(k)
+	}
+	return crypto.MultisigAddress(int(lock.Threshold), keys)
+}
+
+// MultisigKeyIndex returns the position of the key in the lock, or -1 when the
+// key is not part of it.
+func MultisigKeyIndex(lock *proto.MultisigLock, pubKey *crypto.PublicKey) int {
+	for i, k := range lock.PublicKeys {
+		if bytes.Equal(k, pubKey.Bytes()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// VerifyMultisig counts the valid signatures of the input for the lock and
+// checks that they meet its threshold.
+func VerifyMultisig(lock *proto.MultisigLock, input *proto.TxInput, sigHash []byte) error {
+	if len(input.Signatures) != len(lock.PublicKeys) {
+		return fmt.Errorf("expected %d multisig signatures, got %d", len(lock.PublicKeys), len(input.Signatures))
+	}
+
+	valid := 0
+	for i, sig := range input.Signatures {
+		if len(sig) == 0 {
+			continue
+		}
+		signature, err := crypto.ParseSignature(sig)
+		if err != nil {
+			return err
+		}
+		pubKey, err := crypto.ParsePublicKey(lock.PublicKeys[i])
+		if err != nil {
+			return err
+		}
+		if !signature.Verify(pubKey, sigHash) {
+			return fmt.Errorf("invalid signature for multisig key %d", i)
+		}
+		valid++
+	}
+
+	if valid < int(lock.Threshold) {
+		return fmt.Errorf("multisig has %d of %d required signatures", valid, lock.Threshold)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/proto"
+)
+
+const (
+	MinNameLen = 3
+	MaxNameLen = 32
+)
+
+// ValidateName checks that the name is 3 to 32 characters of lowercase
+// letters, digits and inner hyphens.
+func ValidateName(name string) error {
+	if len(name) < MinNameLen || len(name) > MaxNameLen {
+		return fmt.Errorf("name must be %d to %d characters long", MinNameLen, MaxNameLen)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' && i > 0 && i < len(name)-1:
+		default:
+			return fmt.Errorf("invalid character %q in name %q", c, name)
+		}
+	}
+	return nil
+}
+
+// NewNameTransaction returns an unsigned transaction carrying the name
+// operation. Renewals and transfers must be signed by the owner of the name
+// through the first input, expiries can be sent by anyone.
+func NewNameTransaction(op *proto.NameOperation, inputs ...*proto.TxInput) *proto.Transaction {
+	return &proto.Transaction{
+		Version: TransactionVersion,
+		Inputs:  inputs,
+		Payload: &proto.Transaction_Name{
+			Name: op,
+		},
+	}
+}
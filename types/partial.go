@@ -0,0 +1,261 @@
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// PartialTransactionVersion is the version of the partially signed
+// transaction container written by this software.
+const PartialTransactionVersion = 1
+
+// NewPartialTransaction wraps the transaction for signing, stripping any
+// signatures it already has. spent holds the output spent by every input, in
+// input order. Inputs spending plain or HTLC outputs must have the public key
+// of their signer set, since it is covered by the signatures.
+func NewPartialTransaction(tx *proto.Transaction, spent []*proto.TxOutput) (*proto.PartialTransaction, error) {
+	if len(spent) != len(tx.Inputs) {
+		return nil, fmt.Errorf("got %d spent outputs for %d inputs", len(spent), len(tx.Inputs))
+	}
+
+	unsigned := pb.Clone(tx).(*proto.Transaction)
+	for _, input := range unsigned.Inputs {
+		input.Signature = nil
+		input.UnlockScript = nil
+		input.Signatures = nil
+	}
+
+	pt := &proto.PartialTransaction{
+		Version: PartialTransactionVersion,
+		Tx:      unsigned,
+	}
+	for _, output := range spent {
+		pt.Inputs = append(pt.Inputs, &proto.PartialInput{
+			Spent: output,
+		})
+	}
+	return pt, nil
+}
+
+func checkPartialTransaction(pt *proto.PartialTransaction) error {
+	if pt.Version != PartialTransactionVersion {
+		return fmt.Errorf("unsupported partial transaction version %d", pt.Version)
+	}
+	if pt.Tx == nil {
+		return fmt.Errorf("partial transaction has no transaction")
+	}
+	if len(pt.Inputs) != len(pt.Tx.Inputs) {
+		return fmt.Errorf("partial transaction has %d input records for %d inputs", len(pt.Inputs), len(pt.Tx.Inputs))
+	}
+	for i, input := range pt.Inputs {
+		if input.Spent == nil {
+			return fmt.Errorf("input %d has no spent output", i)
+		}
+	}
+	return nil
+}
+
+// canSign reports whether the key is one of the keys able to sign for the
+// input.
+func canSign(input *proto.TxInput, spent *proto.TxOutput, pubKey *crypto.PublicKey) bool {
+	switch {
+	case spent.Multisig != nil:
+		return MultisigKeyIndex(spent.Multisig, pubKey) >= 0
+	case len(spent.LockScript) > 0:
+		return script.IsPayToAddress(spent.LockScript, pubKey.Address())
+	default:
+		return bytes.Equal(input.PublicKey, pubKey.Bytes())
+	}
+}
+
+func partialSignature(input *proto.PartialInput, pubKey []byte) []byte {
+	for _, sig := range input.Signatures {
+		if bytes.Equal(sig.PublicKey, pubKey) {
+			return sig.Signature
+		}
+	}
+	return nil
+}
+
+// SignPartialTransaction adds a signature of the key to every input it can
+// sign and hasn't signed yet. It returns the number of signatures added.
+func SignPartialTransaction(pt *proto.PartialTransaction, privKey *crypto.PrivateKey) (int, error) {
+	if err := checkPartialTransaction(pt); err != nil {
+		return 0, err
+	}
+
+	var (
+		pubKey  = privKey.Public()
+		sigHash = SigHash(pt.Tx)
+		signed  = 0
+	)
+	for i, input := range pt.Inputs {
+		if !canSign(pt.Tx.Inputs[i], input.Spent, pubKey) {
+			continue
+		}
+		if partialSignature(input, pubKey.Bytes()) != nil {
+			continue
+		}
+		input.Signatures = append(input.Signatures, &proto.PartialSignature{
+			PublicKey: pubKey.Bytes(),
+			Signature: privKey.Sign(sigHash).Bytes(),
+		})
+		signed++
+	}
+	return signed, nil
+}
+
+func verifyPartialSignature(sig *proto.PartialSignature, sigHash []byte) error {
+	pubKey, err := crypto.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.ParseSignature(sig.Signature)
+	if err != nil {
+		return err
+	}
+	if !signature.Verify(pubKey, sigHash) {
+		return fmt.Errorf("invalid signature of %x", sig.PublicKey)
+	}
+	return nil
+}
+
+// CombinePartialTransactions merges the signatures collected by several signers
+// of the same transaction. Every signature is verified before it is taken.
+func CombinePartialTransactions(pts ...*proto.PartialTransaction) (*proto.PartialTransaction, error) {
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("no partial transactions to combine")
+	}
+	for _, pt := range pts {
+		if err := checkPartialTransaction(pt); err != nil {
+			return nil, err
+		}
+	}
+
+	combined := pb.Clone(pts[0]).(*proto.PartialTransaction)
+	sigHash := SigHash(combined.Tx)
+	for i, input := range combined.Inputs {
+		input.Signatures = nil
+		for _, pt := range pts {
+			if !bytes.Equal(SigHash(pt.Tx), sigHash) {
+				return nil, fmt.Errorf("partial transactions are for different transactions")
+			}
+			if !pb.Equal(pt.Inputs[i].Spent, input.Spent) {
+				return nil, fmt.Errorf("partial transactions disagree on the output spent by input %d", i)
+			}
+			for _, sig := range pt.Inputs[i].Signatures {
+				if partialSignature(input, sig.PublicKey) != nil {
+					continue
+				}
+				if err := verifyPartialSignature(sig, sigHash); err != nil {
+					return nil, fmt.Errorf("input %d: %w", i, err)
+				}
+				input.Signatures = append(input.Signatures, sig)
+			}
+		}
+	}
+	return combined, nil
+}
+
+// FinalizePartialTransaction places the collected signatures into the inputs
+// and returns the transaction ready to be submitted. It fails when an input
+// doesn't have the signatures it needs.
+func FinalizePartialTransaction(pt *proto.PartialTransaction) (*proto.Transaction, error) {
+	if err := checkPartialTransaction(pt); err != nil {
+		return nil, err
+	}
+
+	var (
+		tx      = pb.Clone(pt.Tx).(*proto.Transaction)
+		sigHash = SigHash(tx)
+	)
+	for i, input := range pt.Inputs {
+		for _, sig := range input.Signatures {
+			if err := verifyPartialSignature(sig, sigHash); err != nil {
+				return nil, fmt.Errorf("input %d: %w", i, err)
+			}
+		}
+		if err := finalizeInput(tx.Inputs[i], input); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	return tx, nil
+}
+
+func finalizeInput(txInput *proto.TxInput, input *proto.PartialInput) error {
+	spent := input.Spent
+
+	if spent.Multisig != nil {
+		var (
+			sigs  = make([][]byte, len(spent.Multisig.PublicKeys))
+			count = 0
+		)
+		for i, k := range spent.Multisig.PublicKeys {
+			if sig := partialSignature(input, k); sig != nil {
+				sigs[i] = sig
+				count++
+			}
+		}
+		if count < int(spent.Multisig.Threshold) {
+			return fmt.Errorf("has %d of %d required multisig signatures", count, spent.Multisig.Threshold)
+		}
+		txInput.Signatures = sigs
+		return nil
+	}
+
+	if len(spent.LockScript) > 0 {
+		addr, ok := script.ExtractAddress(spent.LockScript)
+		if !ok {
+			return fmt.Errorf("can't finalize a non-standard lock script")
+		}
+		for _, sig := range input.Signatures {
+			pubKey, err := crypto.ParsePublicKey(sig.PublicKey)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(pubKey.Address().Bytes(), addr.Bytes()) {
+				txInput.UnlockScript = script.SignatureScript(crypto.SignatureFromBytes(sig.Signature), pubKey)
+				return nil
+			}
+		}
+		return fmt.Errorf("missing signature for %s", addr)
+	}
+
+	sig := partialSignature(input, txInput.PublicKey)
+	if sig == nil {
+		return fmt.Errorf("missing signature for public key %x", txInput.PublicKey)
+	}
+	txInput.Signature = sig
+	return nil
+}
+
+// EncodePartialTransaction returns the text form of the partial transaction,
+// to be passed between signers.
+func EncodePartialTransaction(pt *proto.PartialTransaction) (string, error) {
+	b, err := pb.Marshal(pt)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func DecodePartialTransaction(s string) (*proto.PartialTransaction, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partial transaction encoding: %w", err)
+	}
+	pt := &proto.PartialTransaction{}
+	if err := pb.Unmarshal(b, pt); err != nil {
+		return nil, fmt.Errorf("invalid partial transaction: %w", err)
+	}
+	if err := checkPartialTransaction(pt); err != nil {
+		return nil, err
+	}
+	return pt, nil
+}
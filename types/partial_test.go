@@ -0,0 +1,99 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	"github.com/mhg14/ChlockBane/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiPartySigning(t *testing.T) {
+	var (
+		alice = crypto.GeneratePrivateKey()
+		bob   = crypto.GeneratePrivateKey()
+		carol = crypto.GeneratePrivateKey()
+		owner = crypto.GeneratePrivateKey()
+	)
+
+	multisig := NewMultisigOutput(700, 2, alice.Public(), bob.Public(), carol.Public())
+	plain := &proto.TxOutput{Amount: 200, Address: owner.Public().Address().Bytes()}
+	scripted := &proto.TxOutput{Amount: 100, LockScript: script.PayToAddress(owner.Public().Address())}
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{
+			{PrevTxHash: util.RandomHash()},
+			{PrevTxHash: util.RandomHash(), PublicKey: owner.Public().Bytes()},
+			{PrevTxHash: util.RandomHash()},
+		},
+		Outputs: []*proto.TxOutput{{Amount: 1000, Address: carol.Public().Address().Bytes()}},
+	}
+	pt, err := NewPartialTransaction(tx, []*proto.TxOutput{multisig, plain, scripted})
+	require.Nil(t, err)
+
+	// every party signs its own copy, passed around in text form
+	sign := func(key *crypto.PrivateKey, expected int) *proto.PartialTransaction {
+		s, err := EncodePartialTransaction(pt)
+		require.Nil(t, err)
+		cp, err := DecodePartialTransaction(s)
+		require.Nil(t, err)
+		n, err := SignPartialTransaction(cp, key)
+		require.Nil(t, err)
+		assert.Equal(t, expected, n)
+		return cp
+	}
+	fromAlice := sign(alice, 1)
+	fromOwner := sign(owner, 2)
+
+	_, err = FinalizePartialTransaction(fromAlice)
+	require.NotNil(t, err)
+
+	combined, err := CombinePartialTransactions(fromAlice, fromOwner)
+	require.Nil(t, err)
+	_, err = FinalizePartialTransaction(combined)
+	assert.ErrorContains(t, err, "1 of 2 required multisig signatures")
+
+	combined, err = CombinePartialTransactions(combined, sign(carol, 1), fromAlice)
+	require.Nil(t, err)
+	assert.Len(t, combined.Inputs[0].Signatures, 2)
+
+	final, err := FinalizePartialTransaction(combined)
+	require.Nil(t, err)
+	sigHash := SigHash(final)
+	assert.Equal(t, SigHash(tx), sigHash)
+	assert.Nil(t, CheckTransactionSignatures(final))
+	assert.Nil(t, VerifyMultisig(multisig.Multisig, final.Inputs[0], sigHash))
+	assert.Nil(t, script.Execute(final.Inputs[2].UnlockScript, scripted.LockScript, &script.Context{SigHash: sigHash}))
+}
+
+func TestCombineRejectsForeignSignatures(t *testing.T) {
+	owner := crypto.GeneratePrivateKey()
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs:  []*proto.TxInput{{PrevTxHash: util.RandomHash(), PublicKey: owner.Public().Bytes()}},
+	}
+	spent := []*proto.TxOutput{{Amount: 1, Address: owner.Public().Address().Bytes()}}
+
+	pt, err := NewPartialTransaction(tx, spent)
+	require.Nil(t, err)
+	_, err = SignPartialTransaction(pt, owner)
+	require.Nil(t, err)
+
+	other, err := NewPartialTransaction(&proto.Transaction{
+		Version: 1,
+		Inputs:  []*proto.TxInput{{PrevTxHash: util.RandomHash(), PublicKey: owner.Public().Bytes()}},
+	}, spent)
+	require.Nil(t, err)
+	_, err = CombinePartialTransactions(pt, other)
+	assert.NotNil(t, err)
+
+	pt.Inputs[0].Signatures[0].Signature[0] ^= 0xff
+	_, err = CombinePartialTransactions(pt)
+	assert.NotNil(t, err)
+	_, err = FinalizePartialTransaction(pt)
+	assert.NotNil(t, err)
+}
@@ -2,14 +2,52 @@ package types
 
 import (
 	"crypto/sha256"
+	"fmt"
 
 	"github.com/mhg14/ChlockBane/crypto"
 	"github.com/mhg14/ChlockBane/proto"
 	pb "google.golang.org/protobuf/proto"
 )
 
+// TransactionVersion is the highest transaction version this software knows
+// the rules of.
+const TransactionVersion = 1
+
+func IsKnownTransactionVersion(version int32) bool {
+	return version >= 1 && version <= TransactionVersion
+}
+
+// LockTimeThreshold separates the two meanings of Transaction.LockTime. Values
+// below it are block heights, values at or above it unix timestamps.
+const LockTimeThreshold = 500_000_000
+
+// IsFinalTransaction reports whether the absolute lock time of the transaction
+// allows it into a block at the given height, whose median time past is
+// medianTime in unix seconds.
+func IsFinalTransaction(tx *proto.Transaction, height int, medianTime int64) bool {
+	if tx.LockTime <= 0 {
+		return true
+	}
+	if tx.LockTime < LockTimeThreshold {
+		return int64(height) >= tx.LockTime
+	}
+	return medianTime >= tx.LockTime
+}
+
 func SignTransaction(pk *crypto.PrivateKey, tx *proto.Transaction) *crypto.Signature {
-	return pk.Sign(HashTransaction(tx))
+	return pk.Sign(SigHash(tx))
+}
+
+// SigHash returns the hash that the inputs of the transaction sign. It covers
+// the whole transaction except the signatures and unlocking scripts.
+func SigHash(tx *proto.Transaction) []byte {
+	unsigned := pb.Clone(tx).(*proto.Transaction)
+	for _, input := range unsigned.Inputs {
+		input.Signature = nil
+		input.UnlockScript = nil
+		input.Signatures = nil
+	}
+	return HashTransaction(unsigned)
 }
 
 func HashTransaction(tx *proto.Transaction) []byte {
@@ -23,19 +61,36 @@ func HashTransaction(tx *proto.Transaction) []byte {
 	return hash[:]
 }
 
+// VerifyTransaction checks the signatures of all inputs that don't spend a
+// script or multisig output. Those are checked against the lock of the spent
+// output by the chain.
 func VerifyTransaction(tx *proto.Transaction) bool {
-	for _, input := range tx.Inputs {
+	return CheckTransactionSignatures(tx) == nil
+}
+
+// CheckTransactionSignatures is like VerifyTransaction, but reports which input
+// is not properly signed. Malformed keys and signatures are reported as errors,
+// so it is safe to use on transactions received from peers.
+func CheckTransactionSignatures(tx *proto.Transaction) error {
+	hash := SigHash(tx)
+	for i, input := range tx.Inputs {
+		if len(input.UnlockScript) > 0 || len(input.Signatures) > 0 {
+			continue
+		}
 		if len(input.Signature) == 0 {
-			panic("the transaction has no signature")
+			return fmt.Errorf("input %d is not signed", i)
 		}
-		sig := crypto.SignatureFromBytes(input.Signature)
-
-		// temporary
-		input.Signature = nil
-		pubKey := crypto.PublicKeyFromBytes(input.PublicKey)
-		if !sig.Verify(pubKey, HashTransaction(tx)) {
-			return false
+		sig, err := crypto.ParseSignature(input.Signature)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+		pubKey, err := crypto.ParsePublicKey(input.PublicKey)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+		if !sig.Verify(pubKey, hash) {
+			return fmt.Errorf("input %d has an invalid signature", i)
 		}
 	}
-	return true
+	return nil
 }
@@ -45,3 +45,24 @@ func TestNewTransaction(t *testing.T) {
 	assert.True(t, VerifyTransaction(tx))
 
 }
+
+func TestCheckMalformedTransaction(t *testing.T) {
+	privKey := crypto.GeneratePrivateKey()
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash: util.RandomHash(),
+			PublicKey:  privKey.Public().Bytes(),
+		}},
+	}
+	tx.Inputs[0].Signature = SignTransaction(privKey, tx).Bytes()
+	assert.Nil(t, CheckTransactionSignatures(tx))
+
+	tx.Inputs[0].PublicKey = []byte{1}
+	assert.ErrorIs(t, CheckTransactionSignatures(tx), crypto.ErrInvalidPublicKey)
+	assert.False(t, VerifyTransaction(tx))
+
+	tx.Inputs[0].PublicKey = privKey.Public().Bytes()
+	tx.Inputs[0].Signature = []byte{1}
+	assert.ErrorIs(t, CheckTransactionSignatures(tx), crypto.ErrInvalidSignature)
+}
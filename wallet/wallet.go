@@ -0,0 +1,372 @@
+// Package wallet keeps track of the outputs owned by a set of keys and builds
+// signed transactions spending them.
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	"github.com/mhg14/ChlockBane/types"
+)
+
+// DerivationPath is the path of the i-th key of a wallet derived from a seed.
+// The coin type is not registered in SLIP-0044.
+const DerivationPath = "m/44'/4848'/0'/0'/%d'"
+
+// Coin is an unspent output owned by one of the keys of the wallet.
+type Coin struct {
+	TxHash   []byte
+	OutIndex uint32
+	Amount   int64
+	Address  crypto.Address
+	// LockScript is set for pay-to-address script outputs, which are spent
+	// with an unlock script instead of a plain signature.
+	LockScript []byte
+}
+
+func (c *Coin) key() string {
+	return fmt.Sprintf("%s_%d", hex.EncodeToString(c.TxHash), c.OutIndex)
+}
+
+type Wallet struct {
+	lock sync.RWMutex
+	// keys maps the bech32 address string of every key to the key. The
+	// first key is used for change outputs.
+	keys      map[string]*crypto.PrivateKey
+	changeKey *crypto.PrivateKey
+	coins     map[string]*Coin
+	// pending holds the coins spent by submitted transactions that are not
+	// in a block yet.
+	pending map[string]bool
+	// spentIn maps the hex hash of every processed block that spent coins
+	// of the wallet to those coins, so they can be restored when the block
+	// is disconnected.
+	spentIn map[string][]*Coin
+}
+
+func New(keys ...*crypto.PrivateKey) *Wallet {
+	w := &Wallet{
+		keys:    make(map[string]*crypto.PrivateKey),
+		coins:   make(map[string]*Coin),
+		pending: make(map[string]bool),
+		spentIn: make(map[string][]*Coin),
+	}
+	for _, k := range keys {
+		w.AddKey(k)
+	}
+	return w
+}
+
+// NewFromSeed returns a wallet holding the first n keys derived from the seed,
+// so the seed alone is enough to restore the wallet.
+func NewFromSeed(seed []byte, n int) (*Wallet, error) {
+	master, err := crypto.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	w := New()
+	for i := 0; i < n; i++ {
+		key, err := master.Derive(fmt.Sprintf(DerivationPath, i))
+		if err != nil {
+			return nil, err
+		}
+		w.AddKey(key.PrivateKey())
+	}
+	return w, nil
+}
+
+// NewFromMnemonic returns a wallet holding the first n keys derived from the
+// seed of the mnemonic and passphrase.
+func NewFromMnemonic(mnemonic, passphrase string, n int) (*Wallet, error) {
+	seed, err := crypto.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromSeed(seed, n)
+}
+
+func (w *Wallet) AddKey(k *crypto.PrivateKey) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.changeKey == nil {
+		w.changeKey = k
+	}
+	w.keys[k.Public().Address().String()] = k
+}
+
+func (w *Wallet) keyFor(addr crypto.Address) (*crypto.PrivateKey, bool) {
+	k, ok := w.keys[addr.String()]
+	return k, ok
+}
+
+// ownedAddress returns the address of the output if it can be spent by one of
+// the keys of the wallet.
+func (w *Wallet) ownedAddress(output *proto.TxOutput) (crypto.Address, bool) {
+	// only plain outputs of the native coin are coins of the wallet
+	if output.Multisig != nil || output.Htlc != nil || output.Channel != nil || len(output.Asset) > 0 {
+		return crypto.Address{}, false
+	}
+
+	var addr crypto.Address
+	if len(output.LockScript) > 0 {
+		extracted, ok := script.ExtractAddress(output.LockScript)
+		if !ok {
+			return crypto.Address{}, false
+		}
+		addr = extracted
+	} else {
+		if len(output.Address) != crypto.AddressLen {
+			return crypto.Address{}, false
+		}
+		addr = crypto.AddressFromBytes(output.Address)
+	}
+
+	_, ok := w.keyFor(addr)
+	return addr, ok
+}
+
+// SignPartialTransaction adds the signatures of all keys of the wallet to the
+// inputs of the partial transaction they can sign. It returns the number of
+// signatures added.
+func (w *Wallet) SignPartialTransaction(pt *proto.PartialTransaction) (int, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	signed := 0
+	for _, k := range w.keys {
+		n, err := types.SignPartialTransaction(pt, k)
+		if err != nil {
+			return signed, err
+		}
+		signed += n
+	}
+	return signed, nil
+}
+
+// ProcessBlock adds the outputs of the block paying to the wallet and removes
+// the coins the block spends. Blocks have to be processed in chain order.
+func (w *Wallet) ProcessBlock(b *proto.Block) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	spent := []*Coin{}
+	for _, tx := range b.Transactions {
+		for _, input := range tx.Inputs {
+			key := fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+			if coin, ok := w.coins[key]; ok {
+				spent = append(spent, coin)
+			}
+			delete(w.coins, key)
+			delete(w.pending, key)
+		}
+
+		hash := types.HashTransaction(tx)
+		for i, output := range tx.Outputs {
+			addr, ok := w.ownedAddress(output)
+			if !ok {
+				continue
+			}
+			coin := &Coin{
+				TxHash:     hash,
+				OutIndex:   uint32(i),
+				Amount:     output.Amount,
+				Address:    addr,
+				LockScript: output.LockScript,
+			}
+			w.coins[coin.key()] = coin
+		}
+	}
+	if len(spent) > 0 {
+		w.spentIn[hex.EncodeToString(types.HashBlock(b))] = spent
+	}
+}
+
+// DisconnectBlock reverts ProcessBlock for a block removed from the tip of the
+// chain: the outputs it paid to the wallet are removed and the coins it spent
+// are spendable again. Blocks have to be disconnected in reverse chain order.
+func (w *Wallet) DisconnectBlock(b *proto.Block) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, tx := range b.Transactions {
+		hash := hex.EncodeToString(types.HashTransaction(tx))
+		for i := range tx.Outputs {
+			delete(w.coins, fmt.Sprintf("%s_%d", hash, i))
+		}
+	}
+
+	blockHash := hex.EncodeToString(types.HashBlock(b))
+	for _, coin := range w.spentIn[blockHash] {
+		w.coins[coin.key()] = coin
+	}
+	delete(w.spentIn, blockHash)
+}
+
+// Coins returns the coins of the wallet that are not spent by a pending
+// transaction, largest first.
+func (w *Wallet) Coins() []*Coin {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.spendableCoins()
+}
+
+func (w *Wallet) spendableCoins() []*Coin {
+	coins := []*Coin{}
+	for key, coin := range w.coins {
+		if !w.pending[key] {
+			coins = append(coins, coin)
+		}
+	}
+	sort.Slice(coins, func(i, j int) bool {
+		if coins[i].Amount != coins[j].Amount {
+			return coins[i].Amount > coins[j].Amount
+		}
+		return coins[i].key() < coins[j].key()
+	})
+	return coins
+}
+
+func (w *Wallet) Balance() int64 {
+	var balance int64
+	for _, coin := range w.Coins() {
+		balance += coin.Amount
+	}
+	return balance
+}
+
+// selectCoins picks the coins to fund target. A single coin covering the
+// target is preferred, the smallest one that does. Otherwise coins are added
+// largest first until the target is reached.
+func selectCoins(coins []*Coin, target int64) ([]*Coin, int64, error) {
+	for i := len(coins) - 1; i >= 0; i-- {
+		if coins[i].Amount >= target {
+			return []*Coin{coins[i]}, coins[i].Amount, nil
+		}
+	}
+
+	var (
+		selected []*Coin
+		total    int64
+	)
+	for _, coin := range coins {
+		selected = append(selected, coin)
+		total += coin.Amount
+		if total >= target {
+			return selected, total, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("insufficient funds, have (%d) need (%d)", total, target)
+}
+
+// CreateTransaction builds and signs a transaction paying amount to the given
+// address and leaving fee unspent. Blocks don't collect fees, so the fee is
+// burned. Whatever is left of the selected coins is paid back to the change key
+// of the wallet.
+func (w *Wallet) CreateTransaction(to crypto.Address, amount, fee int64) (*proto.Transaction, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.createTransaction(to, amount, fee)
+}
+
+func (w *Wallet) createTransaction(to crypto.Address, amount, fee int64) (*proto.Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid amount %d", amount)
+	}
+	if fee < 0 {
+		return nil, fmt.Errorf("invalid fee %d", fee)
+	}
+	if amount > math.MaxInt64-fee {
+		return nil, fmt.Errorf("amount %d plus fee %d overflows", amount, fee)
+	}
+	if w.changeKey == nil {
+		return nil, fmt.Errorf("wallet has no keys")
+	}
+
+	coins, total, err := selectCoins(w.spendableCoins(), amount+fee)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &proto.Transaction{
+		Version: types.TransactionVersion,
+		Outputs: []*proto.TxOutput{{
+			Amount:  amount,
+			Address: to.Bytes(),
+		}},
+	}
+	if change := total - amount - fee; change > 0 {
+		tx.Outputs = append(tx.Outputs, &proto.TxOutput{
+			Amount:  change,
+			Address: w.changeKey.Public().Address().Bytes(),
+		})
+	}
+
+	keys := make([]*crypto.PrivateKey, len(coins))
+	for i, coin := range coins {
+		keys[i], _ = w.keyFor(coin.Address)
+		input := &proto.TxInput{
+			PrevTxHash:   coin.TxHash,
+			PrevOutIndex: coin.OutIndex,
+		}
+		if len(coin.LockScript) == 0 {
+			input.PublicKey = keys[i].Public().Bytes()
+		}
+		tx.Inputs = append(tx.Inputs, input)
+	}
+
+	sigHash := types.SigHash(tx)
+	for i, coin := range coins {
+		sig := keys[i].Sign(sigHash)
+		if len(coin.LockScript) > 0 {
+			tx.Inputs[i].UnlockScript = script.SignatureScript(sig, keys[i].Public())
+			continue
+		}
+		tx.Inputs[i].Signature = sig.Bytes()
+	}
+
+	return tx, nil
+}
+
+// Send creates a transaction like CreateTransaction and submits it to the node.
+// The spent coins are held back from later transactions until a processed
+// block spends them.
+func (w *Wallet) Send(ctx context.Context, client proto.NodeClient, to crypto.Address, amount, fee int64) (*proto.Transaction, error) {
+	w.lock.Lock()
+	tx, err := w.createTransaction(to, amount, fee)
+	if err != nil {
+		w.lock.Unlock()
+		return nil, err
+	}
+	w.setPending(tx, true)
+	w.lock.Unlock()
+
+	if _, err := client.HandleTransaction(ctx, tx); err != nil {
+		w.lock.Lock()
+		w.setPending(tx, false)
+		w.lock.Unlock()
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (w *Wallet) setPending(tx *proto.Transaction, pending bool) {
+	for _, input := range tx.Inputs {
+		key := fmt.Sprintf("%s_%d", hex.EncodeToString(input.PrevTxHash), input.PrevOutIndex)
+		if pending {
+			w.pending[key] = true
+		} else {
+			delete(w.pending, key)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mhg14/ChlockBane/crypto"
+	"github.com/mhg14/ChlockBane/node"
+	"github.com/mhg14/ChlockBane/proto"
+	"github.com/mhg14/ChlockBane/script"
+	"github.com/mhg14/ChlockBane/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const godSeed = "6bc49ae98a0f9a9df49427788eb7c73f30299165035c040ab8b4ef56c97b2480"
+
+type mockClient struct {
+	proto.NodeClient
+	txx []*proto.Transaction
+}
+
+func (c *mockClient) HandleTransaction(ctx context.Context, tx *proto.Transaction, opts ...grpc.CallOption) (*proto.Ack, error) {
+	c.txx = append(c.txx, tx)
+	return &proto.Ack{}, nil
+}
+
+func addBlock(t *testing.T, chain *node.Chain, txx ...*proto.Transaction) *proto.Block {
+	prevBlock, err := chain.GetBlockByHeight(chain.Height())
+	require.Nil(t, err)
+
+	block := &proto.Block{
+		Header: &proto.Header{
+			Version:   1,
+			Height:    prevBlock.Header.Height + 1,
+			PrevHash:  types.HashBlock(prevBlock),
+			Timestamp: time.Now().UnixNano(),
+		},
+		Transactions: txx,
+	}
+	types.SignBlock(crypto.GeneratePrivateKey(), block)
+	require.Nil(t, chain.AddBlock(block))
+	return block
+}
+
+func TestCreateTransaction(t *testing.T) {
+	var (
+		chain     = node.NewChain(node.NewMemoryBlockStore(), node.NewMemoryTXStore())
+		godKey    = crypto.NewPrivateKeyFromSeedString(godSeed)
+		w         = New(godKey)
+		recipient = crypto.GeneratePrivateKey()
+	)
+
+	genesis, err := chain.GetBlockByHeight(0)
+	require.Nil(t, err)
+	w.ProcessBlock(genesis)
+	assert.Equal(t, int64(1000), w.Balance())
+
+	_, err = w.CreateTransaction(recipient.Public().Address(), 1000, 1)
+	require.NotNil(t, err)
+	_, err = w.CreateTransaction(recipient.Public().Address(), 0, 1)
+	require.NotNil(t, err)
+	_, err = w.CreateTransaction(recipient.Public().Address(), 300, -1)
+	require.NotNil(t, err)
+	// the sum of amount and fee must not wrap around to a small number
+	_, err = w.CreateTransaction(recipient.Public().Address(), math.MaxInt64, math.MaxInt64)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "overflows")
+
+	tx, err := w.CreateTransaction(recipient.Public().Address(), 300, 10)
+	require.Nil(t, err)
+	require.Nil(t, chain.ValidateTransaction(tx))
+	require.Equal(t, 2, len(tx.Outputs))
+	assert.Equal(t, int64(690), tx.Outputs[1].Amount)
+
+	w.ProcessBlock(addBlock(t, chain, tx))
+	assert.Equal(t, int64(690), w.Balance())
+
+	// the recipient's wallet picks up the payment
+	rw := New(recipient)
+	for h := 0; h <= chain.Height(); h++ {
+		b, err := chain.GetBlockByHeight(h)
+		require.Nil(t, err)
+		rw.ProcessBlock(b)
+	}
+	assert.Equal(t, int64(300), rw.Balance())
+}
+
+func TestCoinSelection(t *testing.T) {
+	var (
+		chain  = node.NewChain(node.NewMemoryBlockStore(), node.NewMemoryTXStore())
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		alice  = crypto.GeneratePrivateKey()
+		bob    = crypto.GeneratePrivateKey()
+		w      = New(godKey)
+	)
+
+	genesis, err := chain.GetBlockByHeight(0)
+	require.Nil(t, err)
+	w.ProcessBlock(genesis)
+
+	// split the genesis output between two keys of alice's wallet, one of
+	// them behind a pay-to-address script
+	aliceOther := crypto.GeneratePrivateKey()
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{{
+			PrevTxHash: types.HashTransaction(genesis.Transactions[0]),
+			PublicKey:  godKey.Public().Bytes(),
+		}},
+		Outputs: []*proto.TxOutput{
+			{Amount: 100, Address: alice.Public().Address().Bytes()},
+			{Amount: 200, Address: alice.Public().Address().Bytes()},
+			{Amount: 700, LockScript: script.PayToAddress(aliceOther.Public().Address())},
+		},
+	}
+	tx.Inputs[0].Signature = types.SignTransaction(godKey, tx).Bytes()
+
+	aw := New(alice, aliceOther)
+	aw.ProcessBlock(addBlock(t, chain, tx))
+	assert.Equal(t, int64(1000), aw.Balance())
+
+	// the smallest coin covering the amount
+	tx, err = aw.CreateTransaction(bob.Public().Address(), 150, 0)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tx.Inputs))
+	assert.Equal(t, uint32(1), tx.Inputs[0].PrevOutIndex)
+
+	// no single coin is enough
+	tx, err = aw.CreateTransaction(bob.Public().Address(), 850, 5)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(tx.Inputs))
+	require.Nil(t, chain.ValidateTransaction(tx))
+
+	client := &mockClient{}
+	sent, err := aw.Send(context.Background(), client, bob.Public().Address(), 850, 5)
+	require.Nil(t, err)
+	assert.Equal(t, []*proto.Transaction{sent}, client.txx)
+
+	// the coins of the pending transaction can't be spent again
+	assert.Equal(t, int64(100), aw.Balance())
+	_, err = aw.Send(context.Background(), client, bob.Public().Address(), 500, 0)
+	require.NotNil(t, err)
+
+	aw.ProcessBlock(addBlock(t, chain, sent))
+	assert.Equal(t, int64(100+45), aw.Balance())
+}
+
+func TestNewFromSeed(t *testing.T) {
+	seed := make([]byte, 32)
+	seed[0] = 1
+
+	w, err := NewFromSeed(seed, 3)
+	require.Nil(t, err)
+	restored, err := NewFromSeed(seed, 3)
+	require.Nil(t, err)
+
+	assert.Equal(t, 3, len(w.keys))
+	assert.Equal(t, w.changeKey.Public().Address(), restored.changeKey.Public().Address())
+	for addr := range w.keys {
+		_, ok := restored.keys[addr]
+		assert.True(t, ok)
+	}
+}
+
+func TestSignPartialTransaction(t *testing.T) {
+	var (
+		godKey = crypto.NewPrivateKeyFromSeedString(godSeed)
+		cold   = New(godKey)
+	)
+
+	tx := &proto.Transaction{
+		Version: 1,
+		Inputs: []*proto.TxInput{
+			{PrevTxHash: make([]byte, 32), PublicKey: godKey.Public().Bytes()},
+			{PrevTxHash: make([]byte, 32), PrevOutIndex: 1, PublicKey: crypto.GeneratePrivateKey().Public().Bytes()},
+		},
+	}
+	pt, err := types.NewPartialTransaction(tx, []*proto.TxOutput{
+		{Amount: 10, Address: godKey.Public().Address().Bytes()},
+		{Amount: 10, LockScript: script.PayToAddress(godKey.Public().Address())},
+	})
+	require.Nil(t, err)
+
+	signed, err := cold.SignPartialTransaction(pt)
+	require.Nil(t, err)
+	assert.Equal(t, 2, signed)
+
+	final, err := types.FinalizePartialTransaction(pt)
+	require.Nil(t, err)
+	assert.True(t, types.VerifyTransaction(final))
+}
+
+func TestDisconnectBlock(t *testing.T) {
+	var (
+		chain     = node.NewChain(node.NewMemoryBlockStore(), node.NewMemoryTXStore())
+		godKey    = crypto.NewPrivateKeyFromSeedString(godSeed)
+		w         = New(godKey)
+		recipient = crypto.GeneratePrivateKey()
+	)
+
+	genesis, err := chain.GetBlockByHeight(0)
+	require.Nil(t, err)
+	w.ProcessBlock(genesis)
+
+	tx, err := w.CreateTransaction(recipient.Public().Address(), 300, 10)
+	require.Nil(t, err)
+	w.ProcessBlock(addBlock(t, chain, tx))
+	assert.Equal(t, int64(690), w.Balance())
+
+	block, err := chain.DisconnectTip()
+	require.Nil(t, err)
+	w.DisconnectBlock(block)
+	assert.Equal(t, int64(1000), w.Balance())
+
+	// the restored coin can be spent again
+	tx, err = w.CreateTransaction(recipient.Public().Address(), 500, 0)
+	require.Nil(t, err)
+	require.Nil(t, chain.ValidateTransaction(tx))
+}